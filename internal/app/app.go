@@ -4,12 +4,23 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"routinist/internal/auth"
+	"routinist/internal/auth/oidc"
 	"routinist/internal/domain/model"
+	"routinist/internal/events"
+	"routinist/internal/mail"
+	"routinist/internal/notify"
+	"routinist/internal/ratelimit"
 	"routinist/internal/seed"
+	"strconv"
+	"strings"
+	"time"
 
 	"routinist/internal/controller/http"
+	v1 "routinist/internal/controller/v1"
 	"routinist/internal/repository"
 	"routinist/internal/usecase"
+	"routinist/internal/webhook"
 	"routinist/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +38,13 @@ func Run() {
 		log.Fatal("DATABASE_URL environment variable is not set")
 	}
 
+	// Load the keys access tokens are signed with
+	keys, err := auth.LoadKeyRing()
+	if err != nil {
+		log.Fatalf("Unable to load JWT keys: %v", err)
+	}
+	auth.UseKeyRing(keys)
+
 	// Connect to database
 	dbpool, err := gorm.Open(postgres.Open(dbURL), &gorm.Config{})
 	if err != nil {
@@ -35,7 +53,14 @@ func Run() {
 
 	err = dbpool.AutoMigrate(
 		&model.User{}, &model.Unit{}, &model.Habit{}, &model.HabitUnit{}, &model.UserHabit{},
-		&model.HabitProgress{},
+		&model.HabitProgress{}, &model.ProgressEntry{}, &model.Streak{}, &model.MilestoneAward{},
+		&model.RefreshToken{}, &model.PasswordResetToken{}, &model.EmailVerificationToken{},
+		&model.ExternalIdentity{}, &model.TwoFactor{}, &model.RecoveryCode{}, &model.LoginChallenge{},
+		&model.RateLimitBucket{}, &model.Reminder{},
+		&model.Device{}, &model.PushDelivery{},
+		&model.WebhookSubscription{}, &model.WebhookDelivery{},
+		&model.OutboxEvent{}, &model.ProcessedEvent{},
+		&model.Achievement{}, &model.UserAchievement{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrations database: %v", err)
@@ -44,17 +69,47 @@ func Run() {
 	seed.Seed(dbpool, l)
 
 	// Initialize Gin router
-	router := gin.Default()
+	router, err := newRouter()
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 	authRepo := repository.NewAuthRepo(dbpool, l)
 	habitRepo := repository.NewHabitRepo(dbpool, l)
 	userRepo := repository.NewUserRepo(dbpool, l)
+	streakRepo := repository.NewStreakRepo(dbpool, l)
+	reminderRepo := repository.NewReminderRepo(dbpool, l)
+	deviceRepo := repository.NewDeviceRepo(dbpool, l)
+	webhookRepo := repository.NewWebhookRepo(dbpool, l)
+	outboxRepo := repository.NewOutboxRepo(dbpool, l)
+	achievementRepo := repository.NewAchievementRepo(dbpool, l)
 
 	// Initialize usecase
-	authUseCase := usecase.NewAuthUseCase(authRepo, habitRepo, l)
-	habitUseCase := usecase.NewHabitUseCase(habitRepo, userRepo, l)
+	mailer := newMailer(l)
+	authUseCase := usecase.NewAuthUseCase(authRepo, habitRepo, userRepo, mailer, oidcProviders(), l)
+	webhookUseCase := usecase.NewWebhookUseCase(webhookRepo, webhook.NewSender(os.Getenv("WEBHOOK_ALLOW_PRIVATE") == "true"), l)
+	habitUseCase := usecase.NewHabitUseCase(habitRepo, userRepo, streakRepo, outboxRepo, backfillDays(), l)
+	userUseCase := usecase.NewUserUseCase(userRepo, habitRepo, l)
+	deviceUseCase := usecase.NewDeviceUseCase(deviceRepo, l)
+	notifier := newNotifier(mailer, deviceRepo, l)
+	reminderUseCase := usecase.NewReminderUseCase(reminderRepo, habitRepo, notifier, l)
+	notificationUseCase := usecase.NewNotificationUseCase(userRepo, notifier, l)
+	achievementUseCase := usecase.NewAchievementUseCase(achievementRepo, habitRepo, outboxRepo, l)
+
+	// Subscribers to the events in the outbox. The names record which events
+	// each one handled, so keep them stable.
+	relay := usecase.NewEventRelay(outboxRepo, l)
+	relay.Subscribe(usecase.StreaksConsumer, habitUseCase.UpdateStreaks, usecase.StreakEventTypes...)
+	relay.Subscribe("achievements", achievementUseCase.EvaluateAchievements, events.HabitCompleted)
+	relay.Subscribe("notifications", notificationUseCase.Notify, events.MilestoneReached, events.AchievementUnlocked)
+	relay.Subscribe("webhooks", webhookUseCase.QueueDeliveries)
+
+	// Relay events, send reminders and webhooks in the background
+	go runEventRelay(relay, time.Second, l)
+	go runReminderScheduler(reminderUseCase, time.Minute, l)
+	go runWebhookDeliverer(webhookUseCase, 10*time.Second, l)
 
 	// Setup routes
-	http.NewRouter(router, l, authUseCase, habitUseCase)
+	http.NewRouter(router, l, authUseCase, habitUseCase, userUseCase, reminderUseCase, deviceUseCase, webhookUseCase, achievementUseCase, authLimiters(dbpool), os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true")
 
 	// Get port from environment variable or use default
 	port := os.Getenv("PORT")
@@ -67,3 +122,172 @@ func Run() {
 		log.Fatalf("Server failed to start: %v", err)
 	}
 }
+
+// newRouter creates the Gin router. Client IPs are only read from
+// X-Forwarded-For when the request comes from one of TRUSTED_PROXIES, a
+// comma-separated list of IPs or CIDRs; by default no proxy is trusted.
+func newRouter() (*gin.Engine, error) {
+	router := gin.Default()
+
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		return nil, err
+	}
+
+	return router, nil
+}
+
+// backfillDays reads how many days back progress may be logged from
+// BACKFILL_WINDOW_DAYS, defaulting to a week.
+func backfillDays() int {
+	days, err := strconv.Atoi(os.Getenv("BACKFILL_WINDOW_DAYS"))
+	if err != nil || days < 0 {
+		return 7
+	}
+	return days
+}
+
+// newMailer picks how emails are delivered from MAIL_DRIVER: smtp, file (into
+// MAIL_DIR) or log, the default.
+func newMailer(l *logger.Logger) mail.Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Routinist <no-reply@routinist.app>"
+	}
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		return mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		})
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return mail.NewFileMailer(dir, from)
+	default:
+		return mail.NewLogMailer(l)
+	}
+}
+
+// oidcProviders reads the OpenID Connect providers users may sign in with.
+// OIDC_PROVIDERS lists their names, e.g. "google,apple", and each is
+// configured with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID and
+// OIDC_<NAME>_JWKS_URL.
+func oidcProviders() map[string]oidc.TokenVerifier {
+	providers := make(map[string]oidc.TokenVerifier)
+
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := oidc.Provider{
+			Name:     name,
+			Issuer:   os.Getenv(prefix + "ISSUER"),
+			ClientID: os.Getenv(prefix + "CLIENT_ID"),
+			JWKSURL:  os.Getenv(prefix + "JWKS_URL"),
+		}
+
+		if provider.Issuer == "" || provider.ClientID == "" || provider.JWKSURL == "" {
+			log.Fatalf("OIDC provider %s needs %sISSUER, %sCLIENT_ID and %sJWKS_URL", name, prefix, prefix, prefix)
+		}
+
+		providers[name] = oidc.NewVerifier(provider, oidc.NewRemoteKeySet(provider.JWKSURL))
+	}
+
+	return providers
+}
+
+// authLimiters throttles logins, signups and password reset emails. Failures are kept in memory, or
+// in Postgres when RATE_LIMIT_STORE=postgres so that every instance shares
+// them.
+func authLimiters(db *gorm.DB) v1.AuthLimiters {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if os.Getenv("RATE_LIMIT_STORE") == "postgres" {
+		store = ratelimit.NewPostgresStore(db)
+	}
+
+	return v1.AuthLimiters{
+		Account: ratelimit.New(store, ratelimit.Policy{
+			FreeAttempts:    5,
+			BaseDelay:       time.Second,
+			MaxDelay:        5 * time.Minute,
+			LockoutAfter:    10,
+			LockoutDuration: 15 * time.Minute,
+			Window:          time.Hour,
+		}),
+		IP: ratelimit.New(store, ratelimit.Policy{
+			FreeAttempts:    20,
+			BaseDelay:       time.Second,
+			MaxDelay:        5 * time.Minute,
+			LockoutAfter:    50,
+			LockoutDuration: time.Hour,
+			Window:          time.Hour,
+		}),
+		Register: ratelimit.New(store, ratelimit.Policy{
+			FreeAttempts: 5,
+			BaseDelay:    30 * time.Second,
+			MaxDelay:     time.Hour,
+			Window:       24 * time.Hour,
+		}),
+		PasswordReset: ratelimit.New(store, ratelimit.Policy{
+			FreeAttempts: 3,
+			BaseDelay:    time.Minute,
+			MaxDelay:     time.Hour,
+			Window:       24 * time.Hour,
+		}),
+	}
+}
+
+// newNotifier picks how reminders are delivered from NOTIFIER, a
+// comma-separated list of push (to the user's devices), webhook (to
+// NOTIFY_WEBHOOK_URL), email and log, the default.
+func newNotifier(mailer mail.Mailer, devices notify.DeviceStore, l *logger.Logger) notify.Notifier {
+	var notifiers notify.MultiNotifier
+
+	for _, name := range strings.Split(os.Getenv("NOTIFIER"), ",") {
+		switch strings.TrimSpace(name) {
+		case "push":
+			notifiers = append(notifiers, notify.NewPushNotifier(devices, newPushProvider(l), l))
+		case "webhook":
+			url := os.Getenv("NOTIFY_WEBHOOK_URL")
+			if url == "" {
+				log.Fatal("NOTIFY_WEBHOOK_URL environment variable is not set")
+			}
+			notifiers = append(notifiers, notify.NewWebhookNotifier(url))
+		case "email":
+			notifiers = append(notifiers, notify.NewEmailNotifier(mailer))
+		}
+	}
+
+	if len(notifiers) == 0 {
+		return notify.NewLogNotifier(l)
+	}
+	return notifiers
+}
+
+// newPushProvider picks the push service from PUSH_PROVIDER. Only the fake
+// provider, which logs pushes, is available so far.
+func newPushProvider(l *logger.Logger) notify.PushProvider {
+	switch os.Getenv("PUSH_PROVIDER") {
+	case "", "fake":
+		return notify.NewFakePushProvider(l)
+	default:
+		log.Fatalf("Unknown PUSH_PROVIDER %q", os.Getenv("PUSH_PROVIDER"))
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The per-IP rate limits key on c.ClientIP, so a client must not be able to
+// pick its own IP with X-Forwarded-For.
+func TestClientIPIgnoresForwardedForFromUntrustedClients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies string
+		remoteAddr     string
+		forwardedFor   string
+		want           string
+	}{
+		{"no proxy trusted", "", "203.0.113.7:4321", "198.51.100.1", "203.0.113.7"},
+		{"client is not the trusted proxy", "10.0.0.1", "203.0.113.7:4321", "198.51.100.1", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.1", "10.0.0.1:4321", "198.51.100.1", "198.51.100.1"},
+		{"trusted proxy range", "10.0.0.0/8, 192.168.0.1", "10.1.2.3:4321", "198.51.100.1", "198.51.100.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRUSTED_PROXIES", tt.trustedProxies)
+
+			router, err := newRouter()
+			if err != nil {
+				t.Fatal(err)
+			}
+			router.GET("/ip", func(c *gin.Context) {
+				c.String(http.StatusOK, c.ClientIP())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if got := w.Body.String(); got != tt.want {
+				t.Fatalf("client IP is %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRouterRejectsInvalidTrustedProxies(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "not-an-ip")
+
+	if _, err := newRouter(); err == nil {
+		t.Fatal("expected an error for an invalid proxy")
+	}
+}
@@ -0,0 +1,44 @@
+package app
+
+import (
+	"routinist/internal/usecase"
+	"routinist/pkg/logger"
+	"time"
+)
+
+// runEvery runs job every interval for as long as the app runs, logging how
+// much it did with done. Each job claims the work it picks up before doing
+// it, so several instances can run it side by side.
+func runEvery(interval time.Duration, job func(now time.Time) (int, error), done string, l *logger.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		n, err := job(now)
+		if err != nil {
+			l.Error(err)
+			continue
+		}
+
+		if n > 0 && done != "" {
+			l.Info(done, n)
+		}
+	}
+}
+
+// runReminderScheduler sends the reminders that have fallen due.
+func runReminderScheduler(uc usecase.ReminderUseCase, interval time.Duration, l *logger.Logger) {
+	runEvery(interval, uc.SendDueReminders, "sent %d reminders", l)
+}
+
+// runWebhookDeliverer sends queued webhook deliveries and retries failed ones
+// whose backoff has passed.
+func runWebhookDeliverer(uc usecase.WebhookUseCase, interval time.Duration, l *logger.Logger) {
+	runEvery(interval, uc.DeliverDue, "delivered %d webhooks", l)
+}
+
+// runEventRelay hands the events in the outbox to their subscribers. It runs
+// often and relays most events, so it does not log how many.
+func runEventRelay(uc usecase.EventRelay, interval time.Duration, l *logger.Logger) {
+	runEvery(interval, uc.RelayDue, "", l)
+}
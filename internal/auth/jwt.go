@@ -3,66 +3,72 @@ package auth
 import (
 	"errors"
 	"github.com/golang-jwt/jwt/v5"
-	"os"
 	"time"
 )
 
+// Claims of an access token. SessionID ties the token to the refresh token
+// family it was issued with, and Version to the user's token version, so that
+// logging out revokes it before it expires.
 type Claims struct {
-	Email string `json:"email"`
-	ID    uint   `json:"id"`
+	Email     string `json:"email"`
+	ID        uint   `json:"id"`
+	SessionID string `json:"sid"`
+	Version   uint   `json:"ver"`
 	jwt.RegisteredClaims
 }
 
-func GenerateJWT(email string, id uint) (string, error) {
-	claims := jwt.MapClaims{
-		"email": email,
-		"id":    id,
-		"exp":   time.Now().Add(time.Hour * 72).Unix(),
-	}
-	secret := []byte(os.Getenv("JWT_SECRET"))
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(secret)
+// Session is what is known about the session of a valid access token.
+type Session struct {
+	UserID        uint
+	EmailVerified bool
 }
 
-func ParseJWT(tokenStr string) (*Claims, error) {
-	secret := []byte(os.Getenv("JWT_SECRET"))
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
-
+func GenerateJWT(email string, id uint, sessionId string, version uint) (string, error) {
+	jti, err := GenerateSessionID()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
-	claims, ok := token.Claims.(*Claims)
-	if !ok {
-		return nil, err
+	now := time.Now()
+	claims := Claims{
+		Email:     email,
+		ID:        id,
+		SessionID: sessionId,
+		Version:   version,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+		},
 	}
-
-	return claims, nil
+	ring, err := currentKeyRing()
+	if err != nil {
+		return "", err
+	}
+	return ring.Sign(claims)
 }
 
-func CheckToken(tokenStr string) (*Claims, error) {
-	secret := []byte(os.Getenv("JWT_SECRET"))
-
-	// Parse token with claims
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return secret, nil
-	})
+// ParseJWT verifies an access token against the key ring. Tokens signed with
+// an unknown key or an algorithm other than their key's are rejected.
+func ParseJWT(tokenStr string) (*Claims, error) {
+	ring, err := currentKeyRing()
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate token structure
-	claims, ok := token.Claims.(*Claims)
-	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
+	claims := &Claims{}
+	token, err := ring.Parse(tokenStr, claims)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check expiration
-	if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-		return nil, errors.New("token expired")
+	if !token.Valid {
+		return nil, errors.New("invalid token")
 	}
 
 	return claims, nil
 }
+
+func CheckToken(tokenStr string) (*Claims, error) {
+	return ParseJWT(tokenStr)
+}
@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoKeyRing           = errors.New("no signing keys configured")
+	ErrUnknownKeyID        = errors.New("unknown key id")
+	ErrUnexpectedAlgorithm = errors.New("unexpected signing algorithm")
+)
+
+// Key signs or verifies access tokens. Keys loaded from a public key can only
+// verify.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	sign   interface{}
+	verify interface{}
+}
+
+// NewPrivateKey makes a signing key from an RSA (RS256) or Ed25519 (EdDSA)
+// private key. Its ID is the key's thumbprint.
+func NewPrivateKey(private crypto.Signer) (*Key, error) {
+	key, err := NewPublicKey(private.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	key.sign = private
+	return key, nil
+}
+
+// NewPublicKey makes a verification-only key, such as a key being rotated
+// out.
+func NewPublicKey(public crypto.PublicKey) (*Key, error) {
+	var method jwt.SigningMethod
+	switch public.(type) {
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", public)
+	}
+
+	jwk, err := NewJWK(public, "", method.Alg())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Key{ID: jwk.Thumbprint(), Method: method, verify: public}, nil
+}
+
+// NewHMACKey makes an HS256 key from a shared secret. It has no ID, as
+// tokens signed before asymmetric keys were introduced carry none.
+func NewHMACKey(secret []byte) *Key {
+	return &Key{Method: jwt.SigningMethodHS256, sign: secret, verify: secret}
+}
+
+// KeyRing signs access tokens with one key and accepts tokens signed by any
+// of its keys, so that a new key can be rolled out while tokens signed by the
+// previous one are still valid.
+type KeyRing struct {
+	signing *Key
+	keys    map[string]*Key
+	ordered []*Key
+	methods []string
+}
+
+func NewKeyRing(signing *Key, verification ...*Key) (*KeyRing, error) {
+	if signing == nil || signing.sign == nil {
+		return nil, errors.New("signing key cannot sign")
+	}
+
+	ring := &KeyRing{signing: signing, keys: make(map[string]*Key)}
+	for _, key := range append([]*Key{signing}, verification...) {
+		if _, ok := ring.keys[key.ID]; ok {
+			continue
+		}
+		ring.keys[key.ID] = key
+		ring.ordered = append(ring.ordered, key)
+		ring.methods = append(ring.methods, key.Method.Alg())
+	}
+
+	return ring, nil
+}
+
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(r.signing.Method, claims)
+	if r.signing.ID != "" {
+		token.Header["kid"] = r.signing.ID
+	}
+	return token.SignedString(r.signing.sign)
+}
+
+// Parse verifies the token with the key named by its kid header. The token
+// must be signed with that key's own algorithm.
+func (r *KeyRing) Parse(tokenStr string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := r.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKeyID
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrUnexpectedAlgorithm
+		}
+
+		return key.verify, nil
+	},
+		jwt.WithValidMethods(r.methods),
+		jwt.WithExpirationRequired(),
+	)
+}
+
+// JWKS lists the public keys, for services verifying our tokens. Shared
+// secrets are never published.
+func (r *KeyRing) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range r.ordered {
+		if _, ok := key.verify.([]byte); ok {
+			continue
+		}
+
+		jwk, err := NewJWK(key.verify, key.ID, key.Method.Alg())
+		if err == nil {
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+
+	return jwks
+}
+
+var keyRing atomic.Pointer[KeyRing]
+
+// UseKeyRing sets the keys GenerateJWT and ParseJWT use.
+func UseKeyRing(r *KeyRing) {
+	keyRing.Store(r)
+}
+
+func currentKeyRing() (*KeyRing, error) {
+	r := keyRing.Load()
+	if r == nil {
+		return nil, ErrNoKeyRing
+	}
+	return r, nil
+}
+
+// PublicJWKS lists the public keys of the key ring in use.
+func PublicJWKS() (JWKS, error) {
+	r, err := currentKeyRing()
+	if err != nil {
+		return JWKS{}, err
+	}
+	return r.JWKS(), nil
+}
+
+// LoadKeyRing reads the keys from the environment. JWT_SIGNING_KEY_FILE is a
+// PEM private key that signs new tokens, and JWT_VERIFICATION_KEY_FILES a
+// comma-separated list of PEM keys whose tokens are still accepted. Without a
+// signing key, JWT_SECRET signs with HS256; with one, JWT_SECRET only
+// verifies tokens issued before the switch.
+func LoadKeyRing() (*KeyRing, error) {
+	var signing *Key
+	var verification []*Key
+
+	if path := os.Getenv("JWT_SIGNING_KEY_FILE"); path != "" {
+		key, err := loadPEMKey(path)
+		if err != nil {
+			return nil, err
+		}
+		if key.sign == nil {
+			return nil, fmt.Errorf("%s is not a private key", path)
+		}
+		signing = key
+	}
+
+	for _, path := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_FILES"), ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		key, err := loadPEMKey(path)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		if signing == nil {
+			signing = NewHMACKey([]byte(secret))
+		} else {
+			verification = append(verification, NewHMACKey([]byte(secret)))
+		}
+	}
+
+	if signing == nil {
+		return nil, errors.New("set JWT_SIGNING_KEY_FILE or JWT_SECRET")
+	}
+
+	return NewKeyRing(signing, verification...)
+}
+
+func loadPEMKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not a PEM file", path)
+	}
+
+	if private, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := private.(crypto.Signer); ok {
+			return NewPrivateKey(signer)
+		}
+	}
+
+	if private, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewPrivateKey(private)
+	}
+
+	if public, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		return NewPublicKey(public)
+	}
+
+	if public, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return NewPublicKey(public)
+	}
+
+	return nil, fmt.Errorf("%s holds no supported key", path)
+}
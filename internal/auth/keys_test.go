@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func testClaims(expiresIn time.Duration) *Claims {
+	return &Claims{
+		Email: "user@example.com",
+		ID:    1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+		},
+	}
+}
+
+func signWith(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, claims jwt.Claims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestKeyRingParse(t *testing.T) {
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signing, err := NewPrivateKey(rsaPrivate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A key being rotated out, which still verifies but no longer signs
+	_, oldPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, err := NewPublicKey(oldPrivate.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := []byte("legacy-secret")
+	ring, err := NewKeyRing(signing, old, NewHMACKey(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, strangerPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := ring.Sign(testClaims(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error // the error the token is rejected with
+		invalid bool  // rejected, whatever the error
+	}{
+		{name: "signed by the ring", token: signed},
+		{name: "signed by a rotated-out key", token: signWith(t, jwt.SigningMethodEdDSA, old.ID, oldPrivate, testClaims(time.Hour))},
+		{name: "legacy HS256 without kid", token: signWith(t, jwt.SigningMethodHS256, "", secret, testClaims(time.Hour))},
+		{
+			name:    "unknown kid",
+			token:   signWith(t, jwt.SigningMethodEdDSA, "unknown", strangerPrivate, testClaims(time.Hour)),
+			wantErr: ErrUnknownKeyID,
+		},
+		{
+			// The public key used as an HMAC secret must not verify
+			name:    "HS256 with the RSA key's kid",
+			token:   signWith(t, jwt.SigningMethodHS256, signing.ID, []byte("anything"), testClaims(time.Hour)),
+			wantErr: ErrUnexpectedAlgorithm,
+		},
+		{
+			name:    "EdDSA without kid",
+			token:   signWith(t, jwt.SigningMethodEdDSA, "", strangerPrivate, testClaims(time.Hour)),
+			wantErr: ErrUnexpectedAlgorithm,
+		},
+		{
+			name:    "alg none",
+			token:   signWith(t, jwt.SigningMethodNone, signing.ID, jwt.UnsafeAllowNoneSignatureType, testClaims(time.Hour)),
+			invalid: true,
+		},
+		{
+			name:    "signed with the wrong key under a known kid",
+			token:   signWith(t, jwt.SigningMethodEdDSA, old.ID, strangerPrivate, testClaims(time.Hour)),
+			invalid: true,
+		},
+		{
+			name:    "expired",
+			token:   signWith(t, jwt.SigningMethodHS256, "", secret, testClaims(-time.Minute)),
+			wantErr: jwt.ErrTokenExpired,
+		},
+		{
+			name:    "without expiry",
+			token:   signWith(t, jwt.SigningMethodHS256, "", secret, &Claims{ID: 1}),
+			invalid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims Claims
+			_, err := ring.Parse(tt.token, &claims)
+
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+			case tt.invalid:
+				if err == nil {
+					t.Fatal("token was accepted")
+				}
+			default:
+				if err != nil {
+					t.Fatalf("token was rejected: %v", err)
+				}
+				if claims.ID != 1 {
+					t.Fatalf("got user %d, want 1", claims.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestNewKeyRingNeedsASigningKey(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, err := NewPublicKey(private.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewKeyRing(public); err == nil {
+		t.Fatal("a public key was accepted as the signing key")
+	}
+}
@@ -0,0 +1,117 @@
+package oidc
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"routinist/internal/auth"
+	"sync"
+	"time"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// KeySet finds the public key an ID token was signed with by its kid.
+type KeySet interface {
+	Key(kid string) (crypto.PublicKey, error)
+}
+
+// StaticKeySet is a fixed set of keys, such as those of a StubIssuer.
+type StaticKeySet map[string]crypto.PublicKey
+
+func (s StaticKeySet) Key(kid string) (crypto.PublicKey, error) {
+	key, ok := s[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+const (
+	jwksMaxAge          = time.Hour
+	jwksRefreshInterval = time.Minute
+)
+
+// RemoteKeySet fetches keys from a JWKS URL. Keys are cached for an hour, and
+// an unknown kid triggers a refresh, at most once a minute, so that keys the
+// provider rotates in are picked up.
+type RemoteKeySet struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *RemoteKeySet) Key(kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	age := time.Since(s.fetchedAt)
+	key, ok := s.keys[kid]
+	if ok && age < jwksMaxAge {
+		return key, nil
+	}
+
+	if age < jwksRefreshInterval {
+		return nil, ErrUnknownKey
+	}
+
+	if err := s.fetch(); err != nil {
+		// Keep using the keys we have while the provider is unreachable
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	if key, ok = s.keys[kid]; !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+func (s *RemoteKeySet) fetch() error {
+	s.fetchedAt = time.Now()
+
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: status %d", resp.StatusCode)
+	}
+
+	var jwks auth.JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range jwks.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		// Skip keys we cannot use rather than failing the whole set
+		key, err := k.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	s.keys = keys
+	return nil
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+// The SHA-1 secret of the RFC 6238 test vectors, "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	at := func(unix int64) time.Time { return time.Unix(unix, 0) }
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		t        time.Time
+		want     bool
+		wantStep int64
+	}{
+		// RFC 6238 appendix B, last six digits
+		{name: "rfc vector 59", secret: rfcSecret, code: "287082", t: at(59), want: true, wantStep: 1},
+		{name: "rfc vector 1111111109", secret: rfcSecret, code: "081804", t: at(1111111109), want: true, wantStep: 37037036},
+		{name: "rfc vector 1234567890", secret: rfcSecret, code: "005924", t: at(1234567890), want: true, wantStep: 41152263},
+
+		{name: "lowercase secret", secret: "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", code: "005924", t: at(1234567890), want: true, wantStep: 41152263},
+
+		// 005924 belongs to step 41152263, which starts at 1234567890
+		{name: "one step early", secret: rfcSecret, code: "005924", t: at(1234567890 - 30), want: true, wantStep: 41152263},
+		{name: "one step late", secret: rfcSecret, code: "005924", t: at(1234567890 + 30), want: true, wantStep: 41152263},
+		{name: "two steps early", secret: rfcSecret, code: "005924", t: at(1234567890 - 60)},
+		{name: "two steps late", secret: rfcSecret, code: "005924", t: at(1234567890 + 60)},
+
+		{name: "wrong code", secret: rfcSecret, code: "005925", t: at(1234567890)},
+		{name: "too short", secret: rfcSecret, code: "05924", t: at(1234567890)},
+		{name: "too long", secret: rfcSecret, code: "0005924", t: at(1234567890)},
+		{name: "invalid secret", secret: "not base32!", code: "005924", t: at(1234567890)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(tt.secret, tt.code, tt.t)
+			if ok != tt.want {
+				t.Fatalf("got valid %t, want %t", ok, tt.want)
+			}
+			if ok && step != tt.wantStep {
+				t.Fatalf("got step %d, want %d", step, tt.wantStep)
+			}
+		})
+	}
+}
+
+func TestGeneratedSecretValidatesItsCodes(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	if _, ok := ValidateTOTP(secret, totpCode(key, now.Unix()/totpPeriod), now); !ok {
+		t.Fatal("the current code was rejected")
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{"k3m9q-w2x7r", "k3m9q-w2x7r"},
+		{"K3M9Q-W2X7R", "k3m9q-w2x7r"},
+		{"k3m9qw2x7r", "k3m9q-w2x7r"},
+		{" k3m9q w2x7r", "k3m9q-w2x7r"},
+		{"short", "short"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.code); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -14,15 +14,34 @@ func NewRouter(
 	l logger.Interface,
 	tAuth usecase.AuthUseCase,
 	tHabit usecase.HabitUsecase,
+	tUser usecase.UserUseCase,
+	tReminder usecase.ReminderUseCase,
+	tDevice usecase.DeviceUseCase,
+	tWebhook usecase.WebhookUseCase,
+	tAchievement usecase.AchievementUseCase,
+	authLimits v1.AuthLimiters,
+	requireVerifiedEmail bool,
 ) {
 	handler.Use(gin.Logger())
 	handler.Use(gin.Recovery())
 
+	v1.NewWellKnownRoutes(&handler.RouterGroup, l)
+
 	h := handler.Group("/api/v1")
 	h.Use(middleware.ContentTypeApplicationJson())
 
+	// Auth routes stay reachable before the email is verified, so that users
+	// can verify it
+	authMiddleware := middleware.JWTAuthMiddleware(tAuth, false)
+	protectedMiddleware := middleware.JWTAuthMiddleware(tAuth, requireVerifiedEmail)
+
 	{
-		v1.NewAuthRoutes(h, tAuth, l)
-		v1.NewHabitRoutes(h, tHabit, l)
+		v1.NewAuthRoutes(h, authMiddleware, tAuth, authLimits, l)
+		v1.NewHabitRoutes(h, protectedMiddleware, tHabit, l)
+		v1.NewUserRoutes(h, protectedMiddleware, tUser, l)
+		v1.NewReminderRoutes(h, protectedMiddleware, tReminder, l)
+		v1.NewDeviceRoutes(h, protectedMiddleware, tDevice, l)
+		v1.NewWebhookRoutes(h, protectedMiddleware, tWebhook, l)
+		v1.NewAchievementRoutes(h, protectedMiddleware, tAchievement, l)
 	}
 }
@@ -0,0 +1,42 @@
+package v1
+
+import (
+	"net/http"
+	"routinist/internal/dto/response"
+	"routinist/internal/usecase"
+	"routinist/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementHandler struct {
+	usecase usecase.AchievementUseCase
+	logger  logger.Interface
+}
+
+func NewAchievementRoutes(handler *gin.RouterGroup, authMiddleware gin.HandlerFunc, t usecase.AchievementUseCase, l logger.Interface) {
+	r := &AchievementHandler{t, l}
+
+	auth := handler.Group("/protected/achievements", authMiddleware)
+	{
+		auth.GET("", r.getAchievements)
+	}
+}
+
+func (h *AchievementHandler) getAchievements(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	achievements, err := h.usecase.GetAchievements(userId)
+	if err != nil {
+		h.logger.Error(err)
+		r.SetMessage("Failed to get achievements")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = achievements
+	c.JSON(http.StatusOK, r)
+}
@@ -6,7 +6,6 @@ import (
 	domainErr "routinist/internal/domain/errors"
 	"routinist/internal/dto/request"
 	"routinist/internal/dto/response"
-	"routinist/internal/middleware"
 	"routinist/internal/usecase"
 	"routinist/pkg/logger"
 	"strings"
@@ -16,22 +15,40 @@ import (
 )
 
 type AuthHandler struct {
-	t usecase.AuthUseCase
-	l logger.Interface
+	t      usecase.AuthUseCase
+	limits AuthLimiters
+	l      logger.Interface
 }
 
-func NewAuthRoutes(handler *gin.RouterGroup, t usecase.AuthUseCase, l logger.Interface) {
-	r := &AuthHandler{t, l}
+func NewAuthRoutes(handler *gin.RouterGroup, authMiddleware gin.HandlerFunc, t usecase.AuthUseCase, limits AuthLimiters, l logger.Interface) {
+	r := &AuthHandler{t, limits, l}
 
 	h1 := handler.Group("/auth")
 	{
 		h1.POST("/register", r.register)
 		h1.POST("/login", r.login)
+		h1.POST("/login/2fa", r.verifyLoginChallenge)
+		h1.POST("/oidc/:provider", r.loginWithProvider)
+		h1.POST("/refresh", r.refresh)
+		h1.POST("/logout", authMiddleware, r.logout)
+		h1.POST("/logout-all", authMiddleware, r.logoutAll)
+		h1.POST("/password-reset/request", r.requestPasswordReset)
+		h1.POST("/password-reset/confirm", r.confirmPasswordReset)
+		h1.POST("/verify-email/confirm", r.confirmEmailVerification)
+		h1.POST("/verify-email/resend", authMiddleware, r.resendEmailVerification)
 	}
 
-	h2 := handler.Group("/auth/protected", middleware.JWTAuthMiddleware())
+	h2 := handler.Group("/auth/protected", authMiddleware)
 	{
 		h2.GET("/check", r.CheckToken)
+		h2.PUT("/password", r.changePassword)
+		h2.POST("/password", r.setPassword)
+		h2.PUT("/email", r.changeEmail)
+		h2.DELETE("/account", r.deleteAccount)
+		h2.POST("/2fa/enroll", r.enrollTwoFactor)
+		h2.POST("/2fa/confirm", r.confirmTwoFactor)
+		h2.POST("/2fa/disable", r.disableTwoFactor)
+		h2.POST("/2fa/recovery-codes", r.regenerateRecoveryCodes)
 	}
 }
 
@@ -72,12 +89,25 @@ func (h *AuthHandler) register(c *gin.Context) {
 		return
 	}
 
+	// Every signup counts against the IP, successful or not
+	ip := limitKey{h.limits.Register, ipKey(c, "register")}
+	if h.throttled(c, ip) {
+		return
+	}
+	h.fail(ip)
+
 	token, err := h.t.Register(&req)
 
 	if err != nil {
 		if errors.Is(err, domainErr.ErrEmailAlreadyExists) {
 			r.SetMessage("User with this email already exists")
 			c.JSON(http.StatusBadRequest, r)
+		} else if errors.Is(err, domainErr.ErrInvalidTimeZone) {
+			r.SetMessage("Time zone must be an IANA name such as Asia/Jakarta")
+			c.JSON(http.StatusBadRequest, r)
+		} else if errors.Is(err, domainErr.ErrInvalidGender) {
+			r.SetMessage(err.Error())
+			c.JSON(http.StatusBadRequest, r)
 		} else {
 			r.SetMessage("Something went wrong")
 			c.JSON(http.StatusInternalServerError, r)
@@ -99,10 +129,25 @@ func (h *AuthHandler) login(c *gin.Context) {
 		return
 	}
 
+	account := limitKey{h.limits.Account, accountKey("login", req.Email)}
+	ip := limitKey{h.limits.IP, ipKey(c, "login")}
+	if h.throttled(c, account, ip) {
+		return
+	}
+
 	token, err := h.t.Login(&req)
 
 	if err != nil {
 		if errors.Is(err, domainErr.ErrInvalidCredentials) {
+			status := h.fail(account, ip)
+			h.l.WithFields(logger.Fields{
+				"event":       "login_failed",
+				"email":       req.Email,
+				"ip":          c.ClientIP(),
+				"failures":    status.Failures,
+				"retry_after": status.RetryAfter.Seconds(),
+			}).Warn("login failed")
+
 			r.SetMessage("Invalid username or password")
 			c.JSON(http.StatusBadRequest, r)
 		} else {
@@ -112,10 +157,420 @@ func (h *AuthHandler) login(c *gin.Context) {
 		return
 	}
 
+	// The IP keeps its failures, so that logging in to one's own account
+	// does not clear guesses made at others. A two-factor challenge is not a
+	// login yet, so the account keeps them too until the code is accepted.
+	if !token.TwoFactorRequired {
+		h.reset(account)
+	}
+
+	r.Data = token
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) loginWithProvider(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.ProviderLoginRequestDTO
+	if err := c.Bind(&req); err != nil || req.IDToken == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	token, err := h.t.LoginWithProvider(c.Param("provider"), &req)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, domainErr.ErrUnknownProvider):
+			r.SetMessage("Unknown identity provider")
+			c.JSON(http.StatusNotFound, r)
+		case errors.Is(err, domainErr.ErrInvalidIDToken):
+			r.SetMessage("Invalid or expired ID token")
+			c.JSON(http.StatusUnauthorized, r)
+		case errors.Is(err, domainErr.ErrEmailNotVerified):
+			r.SetMessage("The provider has not verified your email address")
+			c.JSON(http.StatusForbidden, r)
+		case errors.Is(err, domainErr.ErrInvalidTimeZone):
+			r.SetMessage(err.Error())
+			c.JSON(http.StatusBadRequest, r)
+		default:
+			r.SetMessage("Something went wrong")
+			c.JSON(http.StatusInternalServerError, r)
+		}
+		return
+	}
+
 	r.Data = token
 	c.JSON(http.StatusOK, r)
 }
 
+func (h *AuthHandler) refresh(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.RefreshTokenRequestDTO
+	if err := c.Bind(&req); err != nil || req.RefreshToken == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	token, err := h.t.Refresh(&req)
+
+	if err != nil {
+		if errors.Is(err, domainErr.ErrInvalidRefreshToken) || errors.Is(err, domainErr.ErrRefreshTokenReused) {
+			r.SetMessage("Invalid or expired refresh token")
+			c.JSON(http.StatusUnauthorized, r)
+		} else {
+			r.SetMessage("Something went wrong")
+			c.JSON(http.StatusInternalServerError, r)
+		}
+		return
+	}
+
+	r.Data = token
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) logout(c *gin.Context) {
+	r := response.Response{}
+
+	sessionIDVal, _ := c.Get("session_id")
+	sessionId := sessionIDVal.(string)
+
+	if err := h.t.Logout(sessionId); err != nil {
+		h.l.Error(err)
+		r.SetMessage("Something went wrong")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = "Logged out"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) logoutAll(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	if err := h.t.LogoutAll(userId); err != nil {
+		h.l.Error(err)
+		r.SetMessage("Something went wrong")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = "Logged out of all sessions"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) requestPasswordReset(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.PasswordResetRequestDTO
+	if err := c.Bind(&req); err != nil || req.Email == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	ip := limitKey{h.limits.PasswordReset, ipKey(c, "password-reset")}
+	if h.throttled(c, ip) {
+		return
+	}
+
+	// Every request counts, whether or not the email has an account. An email
+	// past its limit gets the same answer but no further email, so neither
+	// the limit nor the response says whether the account exists.
+	account := limitKey{h.limits.PasswordReset, accountKey("password-reset", req.Email)}
+	if h.blocked(account) {
+		h.fail(ip)
+	} else {
+		h.fail(account, ip)
+
+		if err := h.t.RequestPasswordReset(req.Email); err != nil {
+			h.l.Error(err)
+			r.SetMessage("Something went wrong")
+			c.JSON(http.StatusInternalServerError, r)
+			return
+		}
+	}
+
+	r.Data = "If an account exists for this email, a reset code has been sent"
+	c.JSON(http.StatusAccepted, r)
+}
+
+func (h *AuthHandler) confirmPasswordReset(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.ConfirmPasswordResetRequestDTO
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if len(req.Password) < 6 {
+		r.SetMessage("Password must be at least 6 characters")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if err := h.t.ConfirmPasswordReset(req.Token, req.Password); err != nil {
+		if errors.Is(err, domainErr.ErrInvalidResetToken) {
+			r.SetMessage("Invalid or expired reset code")
+			c.JSON(http.StatusBadRequest, r)
+		} else {
+			r.SetMessage("Something went wrong")
+			c.JSON(http.StatusInternalServerError, r)
+		}
+		return
+	}
+
+	r.Data = "Password updated"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) confirmEmailVerification(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.ConfirmEmailVerificationRequestDTO
+	if err := c.Bind(&req); err != nil || req.Token == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if err := h.t.ConfirmEmailVerification(req.Token); err != nil {
+		if errors.Is(err, domainErr.ErrInvalidVerifyToken) {
+			r.SetMessage("Invalid or expired verification code")
+			c.JSON(http.StatusBadRequest, r)
+		} else if errors.Is(err, domainErr.ErrEmailAlreadyExists) {
+			r.SetMessage("User with this email already exists")
+			c.JSON(http.StatusConflict, r)
+		} else {
+			r.SetMessage("Something went wrong")
+			c.JSON(http.StatusInternalServerError, r)
+		}
+		return
+	}
+
+	r.Data = "Email verified"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) resendEmailVerification(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	if err := h.t.ResendEmailVerification(userId); err != nil {
+		if errors.Is(err, domainErr.ErrEmailAlreadyVerified) {
+			r.SetMessage("Email is already verified")
+			c.JSON(http.StatusConflict, r)
+		} else if errors.Is(err, domainErr.ErrTooManyRequests) {
+			r.SetMessage("Too many verification emails, try again later")
+			c.JSON(http.StatusTooManyRequests, r)
+		} else {
+			h.l.Error(err)
+			r.SetMessage("Something went wrong")
+			c.JSON(http.StatusInternalServerError, r)
+		}
+		return
+	}
+
+	r.Data = "Verification email sent"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) changePassword(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+	sessionIDVal, _ := c.Get("session_id")
+	sessionId := sessionIDVal.(string)
+
+	var req request.ChangePasswordRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if len(req.NewPassword) < 6 {
+		r.SetMessage("Password must be at least 6 characters")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	user := limitKey{h.limits.Account, userKey("reauth", userId)}
+	if h.throttled(c, user) {
+		return
+	}
+
+	reauth := request.ReauthRequestDTO{Password: req.CurrentPassword, Provider: req.Provider, IDToken: req.IDToken}
+	if err := h.t.ChangePassword(userId, sessionId, reauth, req.NewPassword); err != nil {
+		h.l.Error(err)
+		h.failReauth(c, err, userId, user)
+		writeAccountError(c, err)
+		return
+	}
+	h.reset(user)
+
+	r.Data = "Password updated"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) setPassword(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+	sessionIDVal, _ := c.Get("session_id")
+	sessionId := sessionIDVal.(string)
+
+	var req request.SetPasswordRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if len(req.Password) < 6 {
+		r.SetMessage("Password must be at least 6 characters")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if err := h.t.SetPassword(userId, sessionId, &req); err != nil {
+		h.l.Error(err)
+		writeAccountError(c, err)
+		return
+	}
+
+	r.Data = "Password set"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) changeEmail(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.ChangeEmailRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if !strings.Contains(req.Email, "@") || !strings.Contains(req.Email, ".") {
+		r.SetMessage("Invalid email format")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	user := limitKey{h.limits.Account, userKey("reauth", userId)}
+	if h.throttled(c, user) {
+		return
+	}
+
+	if err := h.t.ChangeEmail(userId, req.ReauthRequestDTO, req.Email); err != nil {
+		h.l.Error(err)
+		h.failReauth(c, err, userId, user)
+		writeAccountError(c, err)
+		return
+	}
+	h.reset(user)
+
+	r.Data = "Verification email sent to the new address"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) deleteAccount(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.DeleteAccountRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	user := limitKey{h.limits.Account, userKey("reauth", userId)}
+	if h.throttled(c, user) {
+		return
+	}
+
+	if err := h.t.DeleteAccount(userId, req.ReauthRequestDTO); err != nil {
+		h.l.Error(err)
+		h.failReauth(c, err, userId, user)
+		writeAccountError(c, err)
+		return
+	}
+	h.reset(user)
+
+	r.Data = "Account deleted"
+	c.JSON(http.StatusOK, r)
+}
+
+// failReauth counts a wrong current password against the keys, so that a
+// stolen session cannot be used to guess it.
+func (h *AuthHandler) failReauth(c *gin.Context, err error, userId uint, keys ...limitKey) {
+	if !errors.Is(err, domainErr.ErrInvalidCredentials) {
+		return
+	}
+
+	status := h.fail(keys...)
+	h.l.WithFields(logger.Fields{
+		"event":       "reauth_failed",
+		"user_id":     userId,
+		"ip":          c.ClientIP(),
+		"failures":    status.Failures,
+		"retry_after": status.RetryAfter.Seconds(),
+	}).Warn("current password rejected")
+}
+
+// writeAccountError maps errors of the account endpoints to a status code.
+func writeAccountError(c *gin.Context, err error) {
+	r := response.Response{}
+
+	switch {
+	case errors.Is(err, domainErr.ErrInvalidCredentials):
+		r.SetMessage("Current password is incorrect")
+		c.JSON(http.StatusBadRequest, r)
+	case errors.Is(err, domainErr.ErrReauthRequired):
+		r.SetMessage("Sign in with your identity provider again to confirm")
+		c.JSON(http.StatusUnauthorized, r)
+	case errors.Is(err, domainErr.ErrInvalidIDToken):
+		r.SetMessage("Invalid or expired ID token")
+		c.JSON(http.StatusUnauthorized, r)
+	case errors.Is(err, domainErr.ErrUnknownProvider):
+		r.SetMessage("Unknown identity provider")
+		c.JSON(http.StatusBadRequest, r)
+	case errors.Is(err, domainErr.ErrPasswordAlreadySet):
+		r.SetMessage("This account already has a password")
+		c.JSON(http.StatusConflict, r)
+	case errors.Is(err, domainErr.ErrEmailAlreadyExists):
+		r.SetMessage("User with this email already exists")
+		c.JSON(http.StatusConflict, r)
+	case errors.Is(err, domainErr.ErrTooManyRequests):
+		r.SetMessage("Too many verification emails, try again later")
+		c.JSON(http.StatusTooManyRequests, r)
+	default:
+		r.SetMessage("Something went wrong")
+		c.JSON(http.StatusInternalServerError, r)
+	}
+}
+
 func (h *AuthHandler) CheckToken(c *gin.Context) {
 	expiredAt, exist := c.Get("expired_at")
 	r := response.Response{}
@@ -0,0 +1,112 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/internal/usecase"
+	"routinist/pkg/logger"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceHandler struct {
+	usecase usecase.DeviceUseCase
+	logger  logger.Interface
+}
+
+func NewDeviceRoutes(handler *gin.RouterGroup, authMiddleware gin.HandlerFunc, t usecase.DeviceUseCase, l logger.Interface) {
+	r := &DeviceHandler{t, l}
+
+	auth := handler.Group("/protected/devices", authMiddleware)
+	{
+		auth.GET("", r.getDevices)
+		auth.POST("", r.registerDevice)
+		auth.DELETE("/:device_id", r.unregisterDevice)
+	}
+}
+
+func (h *DeviceHandler) getDevices(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	devices, err := h.usecase.GetDevices(userId)
+	if err != nil {
+		h.logger.Error(err)
+		r.SetMessage("Failed to get devices")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = devices
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *DeviceHandler) registerDevice(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.RegisterDeviceRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	device, err := h.usecase.RegisterDevice(userId, &req)
+	if err != nil {
+		h.logger.Error(err)
+		writeDeviceError(c, err, "Failed to register device")
+		return
+	}
+
+	r.Data = device
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *DeviceHandler) unregisterDevice(c *gin.Context) {
+	r := response.Response{}
+
+	deviceId, err := strconv.Atoi(c.Param("device_id"))
+	if err != nil {
+		r.SetMessage("Invalid device ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	if err := h.usecase.UnregisterDevice(userId, uint(deviceId)); err != nil {
+		h.logger.Error(err)
+		writeDeviceError(c, err, "Failed to unregister device")
+		return
+	}
+
+	r.Data = "Device unregistered"
+	c.JSON(http.StatusOK, r)
+}
+
+func writeDeviceError(c *gin.Context, err error, message string) {
+	r := response.Response{}
+
+	switch {
+	case errors.Is(err, domainErr.ErrDeviceNotFound):
+		r.SetMessage("Device not found")
+		c.JSON(http.StatusNotFound, r)
+	case errors.Is(err, domainErr.ErrInvalidPlatform),
+		errors.Is(err, domainErr.ErrInvalidDeviceToken):
+		r.SetMessage(err.Error())
+		c.JSON(http.StatusBadRequest, r)
+	default:
+		r.SetMessage(message)
+		c.JSON(http.StatusInternalServerError, r)
+	}
+}
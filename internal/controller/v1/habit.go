@@ -1,10 +1,12 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
 	"routinist/internal/dto/request"
 	"routinist/internal/dto/response"
-	"routinist/internal/middleware"
 	"routinist/internal/usecase"
 	"routinist/pkg/logger"
 	"strconv"
@@ -18,23 +20,36 @@ type HabitHandler struct {
 	logger  logger.Interface
 }
 
-func NewHabitRoutes(handler *gin.RouterGroup, t usecase.HabitUsecase, l logger.Interface) {
+func NewHabitRoutes(handler *gin.RouterGroup, authMiddleware gin.HandlerFunc, t usecase.HabitUsecase, l logger.Interface) {
 	r := &HabitHandler{t, l}
 
 	h1 := handler.Group("/habit")
 	{
 		h1.GET("/random", r.getRandomHabits)
+		h1.GET("/units", r.getUnits)
 	}
 
-	auth := handler.Group("/protected/habit", middleware.JWTAuthMiddleware())
+	auth := handler.Group("/protected/habit", authMiddleware)
 	{
 		auth.POST("/create", r.createUserHabit)
+		auth.POST("/custom", r.createCustomHabit)
+		auth.GET("/custom", r.getCustomHabits)
 		auth.GET("/today", r.getTodayHabitProgresses)
 		auth.POST("/:user_habit_id/progress", r.postCreateProgress)
 		auth.GET("/progress-summary", r.GetSummaryProgress)
 		auth.POST("/activity-summary", r.GetActivitySummary)
 		auth.POST("/stats/daily", r.GetUserHabitDailyStats)
 		auth.GET("/user-habits", r.GetUserHabits)
+		auth.GET("/:user_habit_id/streaks", r.GetStreakHistory)
+		auth.POST("/:user_habit_id/skip", r.skipHabitDay)
+		auth.POST("/:user_habit_id/freeze", r.freezeHabitDay)
+		auth.PATCH("/:user_habit_id", r.updateUserHabit)
+		auth.POST("/:user_habit_id/archive", r.archiveUserHabit)
+		auth.POST("/:user_habit_id/restore", r.restoreUserHabit)
+		auth.DELETE("/:user_habit_id", r.deleteUserHabit)
+		auth.GET("/:user_habit_id/entries", r.getProgressEntries)
+		auth.PATCH("/:user_habit_id/entries/:entry_id", r.updateProgressEntry)
+		auth.DELETE("/:user_habit_id/entries/:entry_id", r.deleteProgressEntry)
 	}
 }
 
@@ -52,12 +67,40 @@ func (h *HabitHandler) createUserHabit(c *gin.Context) {
 		return
 	}
 
-	_, err := h.usecase.CreateUserHabit(userId, req.HabitId, &req.UnitId, &req.Goal)
+	schedule, err := toSchedule(req.Schedule)
+	if err != nil {
+		r.SetMessage("Invalid schedule")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	_, err = h.usecase.CreateUserHabit(userId, req.HabitId, &req.UnitId, &req.Goal, model.GoalFrequency(req.GoalFrequency), schedule)
 
 	if err != nil {
 		h.logger.Error(err)
+		if errors.Is(err, domainErr.ErrInvalidGoalFrequency) {
+			r.SetMessage("Goal frequency must be daily, weekly or monthly")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+		if errors.Is(err, domainErr.ErrInvalidSchedule) {
+			r.SetMessage("Invalid schedule")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+		if errors.Is(err, domainErr.ErrHabitNotFound) {
+			r.SetMessage("Habit not found")
+			c.JSON(http.StatusNotFound, r)
+			return
+		}
+		if errors.Is(err, domainErr.ErrInvalidUnit) {
+			r.SetMessage("Unit is not available for this habit")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
 		r.SetMessage("Failed to create user habit")
 		c.JSON(http.StatusInternalServerError, r)
+		return
 	}
 
 	r.Data = "User habit created successfully"
@@ -81,6 +124,88 @@ func (h *HabitHandler) getRandomHabits(c *gin.Context) {
 	c.JSON(http.StatusOK, r)
 }
 
+func (h *HabitHandler) createCustomHabit(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.CreateCustomHabitRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if req.Name == "" || req.Icon == "" {
+		r.SetMessage("Name and icon are required")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if len(req.UnitIds) == 0 {
+		r.SetMessage("At least one unit is required")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	habit, err := h.usecase.CreateCustomHabit(userId, req.Name, req.Icon, model.Measurement(req.Measurement), req.UnitIds, req.DefaultGoal)
+	if err != nil {
+		h.logger.Error(err)
+		switch {
+		case errors.Is(err, domainErr.ErrInvalidMeasurement):
+			r.SetMessage("Invalid measurement")
+			c.JSON(http.StatusBadRequest, r)
+		case errors.Is(err, domainErr.ErrInvalidUnit):
+			r.SetMessage("Units must exist and match the measurement")
+			c.JSON(http.StatusBadRequest, r)
+		case errors.Is(err, domainErr.ErrInvalidGoal):
+			r.SetMessage("Default goal must be more than 0")
+			c.JSON(http.StatusBadRequest, r)
+		default:
+			r.SetMessage("Failed to create custom habit")
+			c.JSON(http.StatusInternalServerError, r)
+		}
+		return
+	}
+
+	r.Data = habit
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *HabitHandler) getCustomHabits(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	habits, err := h.usecase.GetCustomHabits(userId)
+	if err != nil {
+		h.logger.Error(err)
+		r.SetMessage("Failed to get custom habits")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = habits
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *HabitHandler) getUnits(c *gin.Context) {
+	r := response.Response{}
+
+	units, err := h.usecase.GetUnits()
+	if err != nil {
+		h.logger.Error(err)
+		r.SetMessage("Failed to get units")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = units
+	c.JSON(http.StatusOK, r)
+}
+
 func (h *HabitHandler) getTodayHabitProgresses(c *gin.Context) {
 	r := response.Response{}
 
@@ -134,12 +259,26 @@ func (h *HabitHandler) postCreateProgress(c *gin.Context) {
 		return
 	}
 
-	updatedHabit, err := h.usecase.PostCreateHabitProgress(userId, uint(habitId), req.Value)
+	var day time.Time
+	if req.Date != "" {
+		day, e = time.Parse(time.DateOnly, req.Date)
+		if e != nil {
+			r.SetMessage("Date must be formatted YYYY-MM-DD")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+	}
+
+	updatedHabit, err := h.usecase.PostCreateHabitProgress(userId, uint(habitId), day, &req)
 
 	if err != nil {
 		h.logger.Error(err)
-		r.SetMessage("Failed to create habit progress")
-		c.JSON(http.StatusInternalServerError, r)
+		if errors.Is(err, domainErr.ErrInvalidProgressDate) {
+			r.SetMessage("Date must be between the habit creation and today, within the backfill window")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+		writeUserHabitError(c, err, "Failed to create habit progress")
 		return
 	}
 
@@ -156,25 +295,7 @@ func (h *HabitHandler) GetSummaryProgress(c *gin.Context) {
 
 	mode := c.DefaultQuery("mode", "today")
 
-	var from, to time.Time
-	now := time.Now()
-	switch mode {
-	case "today":
-		from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		to = from.Add(24 * time.Hour)
-	case "this_week":
-		weekday := int(now.Weekday())
-		from = time.Date(now.Year(), now.Month(), now.Day()-weekday, 0, 0, 0, 0, now.Location())
-		to = from.AddDate(0, 0, 7)
-	case "this_month":
-		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-		to = time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location())
-	default:
-		from = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		to = from.Add(24 * time.Hour)
-	}
-
-	d, e := h.usecase.GetProgressSummary(userId, from, to)
+	d, e := h.usecase.GetProgressSummary(userId, mode)
 
 	if e != nil {
 		h.logger.Error(e)
@@ -220,7 +341,9 @@ func (h *HabitHandler) GetUserHabits(c *gin.Context) {
 
 	userId := userIDVal.(uint)
 
-	habits, err := h.usecase.GetUserHabits(userId)
+	includeArchived := c.Query("include_archived") == "true"
+
+	habits, err := h.usecase.GetUserHabits(userId, includeArchived)
 	if err != nil {
 		h.logger.Error(err)
 		r.SetMessage("Failed to get today habits")
@@ -257,3 +380,333 @@ func (h *HabitHandler) GetUserHabitDailyStats(c *gin.Context) {
 	r.Data = activitySummary
 	c.JSON(http.StatusOK, r)
 }
+
+func (h *HabitHandler) GetStreakHistory(c *gin.Context) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	history, err := h.usecase.GetStreakHistory(userId, uint(userHabitId))
+	if err != nil {
+		h.logger.Error(err)
+		r.SetMessage("Failed to get streak history")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = history
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *HabitHandler) skipHabitDay(c *gin.Context) {
+	h.excuseHabitDay(c, model.ProgressStatusSkipped)
+}
+
+func (h *HabitHandler) freezeHabitDay(c *gin.Context) {
+	h.excuseHabitDay(c, model.ProgressStatusFrozen)
+}
+
+func (h *HabitHandler) excuseHabitDay(c *gin.Context, status model.ProgressStatus) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.ExcuseProgressRequestDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.Bind(&req); err != nil {
+			r.SetMessage("Invalid request")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+	}
+
+	var day time.Time
+	if req.Date != "" {
+		day, err = time.Parse(time.DateOnly, req.Date)
+		if err != nil {
+			r.SetMessage("Date must be formatted YYYY-MM-DD")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+	}
+
+	result, err := h.usecase.ExcuseHabitDay(userId, uint(userHabitId), day, status)
+	if err != nil {
+		h.logger.Error(err)
+		switch {
+		case errors.Is(err, domainErr.ErrInvalidProgressDate):
+			r.SetMessage("Date must be between the habit creation and today, within the backfill window")
+			c.JSON(http.StatusBadRequest, r)
+		case errors.Is(err, domainErr.ErrProgressCompleted):
+			r.SetMessage("This day is already completed")
+			c.JSON(http.StatusConflict, r)
+		case errors.Is(err, domainErr.ErrNoFreezeTokens):
+			r.SetMessage("No freeze tokens left")
+			c.JSON(http.StatusPaymentRequired, r)
+		default:
+			r.SetMessage("Failed to update habit progress")
+			c.JSON(http.StatusInternalServerError, r)
+		}
+		return
+	}
+
+	r.Data = result
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *HabitHandler) updateUserHabit(c *gin.Context) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.UpdateUserHabitRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	var frequency *model.GoalFrequency
+	if req.GoalFrequency != nil {
+		f := model.GoalFrequency(*req.GoalFrequency)
+		frequency = &f
+	}
+
+	var schedule *model.Schedule
+	if req.Schedule != nil {
+		s, err := toSchedule(req.Schedule)
+		if err != nil {
+			r.SetMessage("Invalid schedule")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+		schedule = &s
+	}
+
+	updated, err := h.usecase.UpdateUserHabit(userId, uint(userHabitId), req.Goal, req.UnitId, frequency, schedule)
+	if err != nil {
+		h.logger.Error(err)
+		writeUserHabitError(c, err, "Failed to update user habit")
+		return
+	}
+
+	r.Data = updated
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *HabitHandler) archiveUserHabit(c *gin.Context) {
+	h.changeUserHabit(c, h.usecase.ArchiveUserHabit, "User habit archived successfully", "Failed to archive user habit")
+}
+
+func (h *HabitHandler) restoreUserHabit(c *gin.Context) {
+	h.changeUserHabit(c, h.usecase.RestoreUserHabit, "User habit restored successfully", "Failed to restore user habit")
+}
+
+func (h *HabitHandler) deleteUserHabit(c *gin.Context) {
+	h.changeUserHabit(c, h.usecase.DeleteUserHabit, "User habit deleted successfully", "Failed to delete user habit")
+}
+
+// changeUserHabit runs an action on the user habit in the path that returns
+// nothing but an error.
+func (h *HabitHandler) changeUserHabit(c *gin.Context, action func(userId uint, userHabitId uint) error, success, failure string) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	if err := action(userId, uint(userHabitId)); err != nil {
+		h.logger.Error(err)
+		writeUserHabitError(c, err, failure)
+		return
+	}
+
+	r.Data = success
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *HabitHandler) getProgressEntries(c *gin.Context) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var day time.Time
+	if date := c.Query("date"); date != "" {
+		day, err = time.Parse(time.DateOnly, date)
+		if err != nil {
+			r.SetMessage("Date must be formatted YYYY-MM-DD")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+	}
+
+	result, err := h.usecase.GetProgressEntries(userId, uint(userHabitId), day)
+	if err != nil {
+		h.logger.Error(err)
+		writeUserHabitError(c, err, "Failed to get progress entries")
+		return
+	}
+
+	r.Data = result
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *HabitHandler) updateProgressEntry(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.UpdateProgressEntryRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if req.Value != nil && *req.Value < 0 {
+		r.SetMessage("Value must be more than 0")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	h.changeProgressEntry(c, func(userId, userHabitId, entryId uint) (*response.DailyProgressDto, error) {
+		return h.usecase.UpdateProgressEntry(userId, userHabitId, entryId, req.Value, req.Note)
+	}, "Failed to update progress entry")
+}
+
+func (h *HabitHandler) deleteProgressEntry(c *gin.Context) {
+	h.changeProgressEntry(c, h.usecase.DeleteProgressEntry, "Failed to delete progress entry")
+}
+
+// changeProgressEntry runs action on the entry addressed by the route and
+// responds with the resulting daily progress.
+func (h *HabitHandler) changeProgressEntry(c *gin.Context, action func(userId, userHabitId, entryId uint) (*response.DailyProgressDto, error), failure string) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	entryId, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil {
+		r.SetMessage("Invalid entry ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	result, err := action(userId, uint(userHabitId), uint(entryId))
+	if err != nil {
+		h.logger.Error(err)
+		writeUserHabitError(c, err, failure)
+		return
+	}
+
+	r.Data = result
+	c.JSON(http.StatusOK, r)
+}
+
+// writeUserHabitError maps user habit errors to a status code, falling back to
+// an internal error with message.
+func writeUserHabitError(c *gin.Context, err error, message string) {
+	r := response.Response{}
+
+	switch {
+	case errors.Is(err, domainErr.ErrHabitNotFound):
+		r.SetMessage("Habit not found")
+		c.JSON(http.StatusNotFound, r)
+	case errors.Is(err, domainErr.ErrHabitArchived):
+		r.SetMessage("Habit is archived")
+		c.JSON(http.StatusConflict, r)
+	case errors.Is(err, domainErr.ErrEntryNotFound):
+		r.SetMessage("Progress entry not found")
+		c.JSON(http.StatusNotFound, r)
+	case errors.Is(err, domainErr.ErrReminderNotFound):
+		r.SetMessage("Reminder not found")
+		c.JSON(http.StatusNotFound, r)
+	case errors.Is(err, domainErr.ErrInvalidGoal),
+		errors.Is(err, domainErr.ErrInvalidUnit),
+		errors.Is(err, domainErr.ErrIncompatibleUnits),
+		errors.Is(err, domainErr.ErrInvalidGoalFrequency),
+		errors.Is(err, domainErr.ErrInvalidSchedule),
+		errors.Is(err, domainErr.ErrInvalidEntrySource),
+		errors.Is(err, domainErr.ErrInvalidProgressDate),
+		errors.Is(err, domainErr.ErrInvalidReminderTime),
+		errors.Is(err, domainErr.ErrTooManyReminders):
+		r.SetMessage(err.Error())
+		c.JSON(http.StatusBadRequest, r)
+	default:
+		r.SetMessage(message)
+		c.JSON(http.StatusInternalServerError, r)
+	}
+}
+
+func toSchedule(req *request.ScheduleRequestDTO) (model.Schedule, error) {
+	schedule := model.Schedule{Type: model.ScheduleEveryDay}
+	if req == nil {
+		return schedule, nil
+	}
+
+	if req.Type != "" {
+		schedule.Type = model.ScheduleType(req.Type)
+	}
+	schedule.Interval = req.Interval
+
+	for _, d := range req.Weekdays {
+		if d < int(time.Sunday) || d > int(time.Saturday) {
+			return schedule, domainErr.ErrInvalidSchedule
+		}
+		schedule.Weekdays |= model.NewWeekdays(time.Weekday(d))
+	}
+
+	if req.StartDate != "" {
+		start, err := time.Parse(time.DateOnly, req.StartDate)
+		if err != nil {
+			return schedule, domainErr.ErrInvalidSchedule
+		}
+		schedule.StartDate = start
+	}
+
+	return schedule, nil
+}
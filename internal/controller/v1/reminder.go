@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"net/http"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/internal/usecase"
+	"routinist/pkg/logger"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReminderHandler struct {
+	usecase usecase.ReminderUseCase
+	logger  logger.Interface
+}
+
+func NewReminderRoutes(handler *gin.RouterGroup, authMiddleware gin.HandlerFunc, t usecase.ReminderUseCase, l logger.Interface) {
+	r := &ReminderHandler{t, l}
+
+	auth := handler.Group("/protected/habit/:user_habit_id/reminders", authMiddleware)
+	{
+		auth.GET("", r.getReminders)
+		auth.POST("", r.createReminder)
+		auth.PATCH("/:reminder_id", r.updateReminder)
+		auth.DELETE("/:reminder_id", r.deleteReminder)
+	}
+}
+
+func (h *ReminderHandler) getReminders(c *gin.Context) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	reminders, err := h.usecase.GetReminders(userId, uint(userHabitId))
+	if err != nil {
+		h.logger.Error(err)
+		writeUserHabitError(c, err, "Failed to get reminders")
+		return
+	}
+
+	r.Data = reminders
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *ReminderHandler) createReminder(c *gin.Context) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	var req request.CreateReminderRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	reminder, err := h.usecase.CreateReminder(userId, uint(userHabitId), &req)
+	if err != nil {
+		h.logger.Error(err)
+		writeUserHabitError(c, err, "Failed to create reminder")
+		return
+	}
+
+	r.Data = reminder
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *ReminderHandler) updateReminder(c *gin.Context) {
+	r := response.Response{}
+
+	userHabitId, reminderId, ok := reminderParams(c)
+	if !ok {
+		return
+	}
+
+	var req request.UpdateReminderRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	reminder, err := h.usecase.UpdateReminder(userId, userHabitId, reminderId, &req)
+	if err != nil {
+		h.logger.Error(err)
+		writeUserHabitError(c, err, "Failed to update reminder")
+		return
+	}
+
+	r.Data = reminder
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *ReminderHandler) deleteReminder(c *gin.Context) {
+	r := response.Response{}
+
+	userHabitId, reminderId, ok := reminderParams(c)
+	if !ok {
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	if err := h.usecase.DeleteReminder(userId, userHabitId, reminderId); err != nil {
+		h.logger.Error(err)
+		writeUserHabitError(c, err, "Failed to delete reminder")
+		return
+	}
+
+	r.Data = "Reminder deleted"
+	c.JSON(http.StatusOK, r)
+}
+
+// reminderParams reads the ids of the reminder addressed by the route,
+// responding with 400 when they are invalid.
+func reminderParams(c *gin.Context) (uint, uint, bool) {
+	r := response.Response{}
+
+	userHabitId, err := strconv.Atoi(c.Param("user_habit_id"))
+	if err != nil {
+		r.SetMessage("Invalid habit ID")
+		c.JSON(http.StatusBadRequest, r)
+		return 0, 0, false
+	}
+
+	reminderId, err := strconv.Atoi(c.Param("reminder_id"))
+	if err != nil {
+		r.SetMessage("Invalid reminder ID")
+		c.JSON(http.StatusBadRequest, r)
+		return 0, 0, false
+	}
+
+	return uint(userHabitId), uint(reminderId), true
+}
@@ -0,0 +1,119 @@
+package v1
+
+import (
+	"math"
+	"net/http"
+	"routinist/internal/dto/response"
+	"routinist/internal/ratelimit"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthLimiters throttle the endpoints that can be used to guess credentials,
+// to create accounts in bulk or to flood inboxes. A nil limiter does not
+// throttle.
+type AuthLimiters struct {
+	Account       *ratelimit.Limiter // failed logins per email, failed two-factor codes and passwords per user
+	IP            *ratelimit.Limiter // failed logins and two-factor codes per client IP
+	Register      *ratelimit.Limiter // signups per client IP
+	PasswordReset *ratelimit.Limiter // reset emails per email and per client IP
+}
+
+type limitKey struct {
+	limiter *ratelimit.Limiter
+	key     string
+}
+
+func accountKey(action string, email string) string {
+	return action + ":account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func userKey(action string, userId uint) string {
+	return action + ":user:" + strconv.FormatUint(uint64(userId), 10)
+}
+
+func ipKey(c *gin.Context, action string) string {
+	return action + ":ip:" + c.ClientIP()
+}
+
+// throttled answers 429 with a Retry-After header when any of the keys is
+// blocked. The limiter failing must not lock everyone out, so errors are only
+// logged.
+func (h *AuthHandler) throttled(c *gin.Context, keys ...limitKey) bool {
+	wait := h.wait(keys...)
+	if wait <= 0 {
+		return false
+	}
+
+	r := response.Response{}
+	r.SetMessage("Too many attempts, please try again later")
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, r)
+	return true
+}
+
+// blocked reports whether any of the keys is blocked, for endpoints that must
+// not tell the client.
+func (h *AuthHandler) blocked(keys ...limitKey) bool {
+	return h.wait(keys...) > 0
+}
+
+// wait returns how long the most restricted key is blocked for.
+func (h *AuthHandler) wait(keys ...limitKey) time.Duration {
+	var wait time.Duration
+	for _, k := range keys {
+		if k.limiter == nil {
+			continue
+		}
+
+		d, err := k.limiter.Allow(k.key)
+		if err != nil {
+			h.l.Error(err)
+			continue
+		}
+
+		if d > wait {
+			wait = d
+		}
+	}
+
+	return wait
+}
+
+// fail records a failure for the keys and returns the status of the most
+// restricted one.
+func (h *AuthHandler) fail(keys ...limitKey) ratelimit.Status {
+	var status ratelimit.Status
+	for _, k := range keys {
+		if k.limiter == nil {
+			continue
+		}
+
+		s, err := k.limiter.Fail(k.key)
+		if err != nil {
+			h.l.Error(err)
+			continue
+		}
+
+		if s.RetryAfter > status.RetryAfter || (s.RetryAfter == status.RetryAfter && s.Failures > status.Failures) {
+			status = s
+		}
+	}
+
+	return status
+}
+
+func (h *AuthHandler) reset(keys ...limitKey) {
+	for _, k := range keys {
+		if k.limiter == nil {
+			continue
+		}
+
+		if err := k.limiter.Reset(k.key); err != nil {
+			h.l.Error(err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *AuthHandler) enrollTwoFactor(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	enrollment, err := h.t.EnrollTwoFactor(userId)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	r.Data = enrollment
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) confirmTwoFactor(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.TwoFactorCodeRequestDTO
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	codes, err := h.t.ConfirmTwoFactor(userId, req.Code)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	r.Data = request.RecoveryCodesDTO{RecoveryCodes: codes}
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) disableTwoFactor(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+	sessionIDVal, _ := c.Get("session_id")
+	sessionId := sessionIDVal.(string)
+
+	var req request.TwoFactorCodeRequestDTO
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	user := limitKey{h.limits.Account, userKey("two-factor", userId)}
+	if h.throttled(c, user) {
+		return
+	}
+
+	if err := h.t.DisableTwoFactor(userId, sessionId, req.Code); err != nil {
+		h.failTwoFactor(c, err, user)
+		writeTwoFactorError(c, err)
+		return
+	}
+	h.reset(user)
+
+	r.Data = "Two-factor authentication disabled"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) regenerateRecoveryCodes(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.TwoFactorCodeRequestDTO
+	if err := c.Bind(&req); err != nil || req.Code == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	user := limitKey{h.limits.Account, userKey("two-factor", userId)}
+	if h.throttled(c, user) {
+		return
+	}
+
+	codes, err := h.t.RegenerateRecoveryCodes(userId, req.Code)
+	if err != nil {
+		h.failTwoFactor(c, err, user)
+		writeTwoFactorError(c, err)
+		return
+	}
+	h.reset(user)
+
+	r.Data = request.RecoveryCodesDTO{RecoveryCodes: codes}
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *AuthHandler) verifyLoginChallenge(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.TwoFactorChallengeRequestDTO
+	if err := c.Bind(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	ip := limitKey{h.limits.IP, ipKey(c, "login")}
+	if h.throttled(c, ip) {
+		return
+	}
+
+	// Failed codes count against the user too, so that logging in again for
+	// a fresh challenge does not give more guesses
+	userId, err := h.t.LoginChallengeUser(req.ChallengeToken)
+	if err != nil {
+		writeTwoFactorError(c, err)
+		return
+	}
+
+	user := limitKey{h.limits.Account, userKey("two-factor", userId)}
+	if h.throttled(c, user) {
+		return
+	}
+
+	token, err := h.t.VerifyLoginChallenge(req.ChallengeToken, req.Code)
+	if err != nil {
+		h.failTwoFactor(c, err, user, ip)
+		writeTwoFactorError(c, err)
+		return
+	}
+	h.reset(user)
+
+	r.Data = token
+	c.JSON(http.StatusOK, r)
+}
+
+// failTwoFactor counts a rejected two-factor code against the keys.
+func (h *AuthHandler) failTwoFactor(c *gin.Context, err error, keys ...limitKey) {
+	if !errors.Is(err, domainErr.ErrInvalidTwoFactorCode) {
+		return
+	}
+
+	status := h.fail(keys...)
+	h.l.WithFields(logger.Fields{
+		"event":       "two_factor_failed",
+		"ip":          c.ClientIP(),
+		"failures":    status.Failures,
+		"retry_after": status.RetryAfter.Seconds(),
+	}).Warn("two-factor code rejected")
+}
+
+func writeTwoFactorError(c *gin.Context, err error) {
+	r := response.Response{}
+
+	switch {
+	case errors.Is(err, domainErr.ErrInvalidTwoFactorCode):
+		r.SetMessage("Invalid two-factor code")
+		c.JSON(http.StatusBadRequest, r)
+	case errors.Is(err, domainErr.ErrInvalidChallenge):
+		r.SetMessage("Invalid or expired login challenge, please log in again")
+		c.JSON(http.StatusUnauthorized, r)
+	case errors.Is(err, domainErr.ErrTwoFactorEnabled):
+		r.SetMessage("Two-factor authentication is already enabled")
+		c.JSON(http.StatusConflict, r)
+	case errors.Is(err, domainErr.ErrTwoFactorNotEnabled):
+		r.SetMessage("Two-factor authentication is not enabled")
+		c.JSON(http.StatusConflict, r)
+	default:
+		r.SetMessage("Something went wrong")
+		c.JSON(http.StatusInternalServerError, r)
+	}
+}
@@ -0,0 +1,113 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/internal/usecase"
+	"routinist/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	usecase usecase.UserUseCase
+	logger  logger.Interface
+}
+
+func NewUserRoutes(handler *gin.RouterGroup, authMiddleware gin.HandlerFunc, t usecase.UserUseCase, l logger.Interface) {
+	r := &UserHandler{t, l}
+
+	auth := handler.Group("/protected/me", authMiddleware)
+	{
+		auth.GET("", r.getProfile)
+		auth.PATCH("", r.updateProfile)
+		auth.PUT("/time-zone", r.updateTimeZone)
+	}
+}
+
+func (h *UserHandler) getProfile(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	profile, err := h.usecase.GetProfile(userId)
+	if err != nil {
+		h.logger.Error(err)
+		r.SetMessage("Failed to get profile")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = profile
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *UserHandler) updateProfile(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.UpdateUserRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	profile, err := h.usecase.UpdateProfile(userId, &req)
+	if err != nil {
+		h.logger.Error(err)
+		switch {
+		case errors.Is(err, domainErr.ErrInvalidTimeZone):
+			r.SetMessage("Time zone must be an IANA name such as Asia/Jakarta")
+			c.JSON(http.StatusBadRequest, r)
+		case errors.Is(err, domainErr.ErrInvalidName),
+			errors.Is(err, domainErr.ErrInvalidGender),
+			errors.Is(err, domainErr.ErrInvalidWeekStart),
+			errors.Is(err, domainErr.ErrInvalidUnitSystem):
+			r.SetMessage(err.Error())
+			c.JSON(http.StatusBadRequest, r)
+		default:
+			r.SetMessage("Failed to update profile")
+			c.JSON(http.StatusInternalServerError, r)
+		}
+		return
+	}
+
+	r.Data = profile
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *UserHandler) updateTimeZone(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	var req request.UpdateTimeZoneRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	if err := h.usecase.UpdateTimeZone(userId, req.TimeZone); err != nil {
+		h.logger.Error(err)
+		if errors.Is(err, domainErr.ErrInvalidTimeZone) {
+			r.SetMessage("Time zone must be an IANA name such as Asia/Jakarta")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+		r.SetMessage("Failed to update time zone")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = "Time zone updated"
+	c.JSON(http.StatusOK, r)
+}
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/internal/usecase"
+	"routinist/pkg/logger"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	usecase usecase.WebhookUseCase
+	logger  logger.Interface
+}
+
+func NewWebhookRoutes(handler *gin.RouterGroup, authMiddleware gin.HandlerFunc, t usecase.WebhookUseCase, l logger.Interface) {
+	r := &WebhookHandler{t, l}
+
+	auth := handler.Group("/protected/webhooks", authMiddleware)
+	{
+		auth.GET("", r.getSubscriptions)
+		auth.POST("", r.createSubscription)
+		auth.DELETE("/:subscription_id", r.deleteSubscription)
+		auth.GET("/:subscription_id/deliveries", r.getDeliveries)
+	}
+}
+
+func (h *WebhookHandler) getSubscriptions(c *gin.Context) {
+	r := response.Response{}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	subscriptions, err := h.usecase.GetSubscriptions(userId)
+	if err != nil {
+		h.logger.Error(err)
+		r.SetMessage("Failed to get webhooks")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	r.Data = subscriptions
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *WebhookHandler) createSubscription(c *gin.Context) {
+	r := response.Response{}
+
+	var req request.CreateWebhookRequestDTO
+	if err := c.Bind(&req); err != nil {
+		r.SetMessage("Invalid request")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	subscription, err := h.usecase.CreateSubscription(userId, &req)
+	if err != nil {
+		h.logger.Error(err)
+		writeWebhookError(c, err, "Failed to create webhook")
+		return
+	}
+
+	r.Data = subscription
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *WebhookHandler) deleteSubscription(c *gin.Context) {
+	r := response.Response{}
+
+	subscriptionId, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		r.SetMessage("Invalid webhook ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	if err := h.usecase.DeleteSubscription(userId, uint(subscriptionId)); err != nil {
+		h.logger.Error(err)
+		writeWebhookError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	r.Data = "Webhook deleted"
+	c.JSON(http.StatusOK, r)
+}
+
+func (h *WebhookHandler) getDeliveries(c *gin.Context) {
+	r := response.Response{}
+
+	subscriptionId, err := strconv.Atoi(c.Param("subscription_id"))
+	if err != nil {
+		r.SetMessage("Invalid webhook ID")
+		c.JSON(http.StatusBadRequest, r)
+		return
+	}
+
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil {
+			r.SetMessage("Invalid limit")
+			c.JSON(http.StatusBadRequest, r)
+			return
+		}
+	}
+
+	userIDVal, _ := c.Get("user_id")
+	userId := userIDVal.(uint)
+
+	deliveries, err := h.usecase.GetDeliveries(userId, uint(subscriptionId), c.Query("status"), limit)
+	if err != nil {
+		h.logger.Error(err)
+		writeWebhookError(c, err, "Failed to get webhook deliveries")
+		return
+	}
+
+	r.Data = deliveries
+	c.JSON(http.StatusOK, r)
+}
+
+func writeWebhookError(c *gin.Context, err error, message string) {
+	r := response.Response{}
+
+	switch {
+	case errors.Is(err, domainErr.ErrWebhookNotFound):
+		r.SetMessage("Webhook not found")
+		c.JSON(http.StatusNotFound, r)
+	case errors.Is(err, domainErr.ErrInvalidWebhookURL),
+		errors.Is(err, domainErr.ErrInvalidEventType),
+		errors.Is(err, domainErr.ErrTooManyWebhooks):
+		r.SetMessage(err.Error())
+		c.JSON(http.StatusBadRequest, r)
+	default:
+		r.SetMessage(message)
+		c.JSON(http.StatusInternalServerError, r)
+	}
+}
@@ -0,0 +1,39 @@
+package v1
+
+import (
+	"net/http"
+	"routinist/internal/auth"
+	"routinist/internal/dto/response"
+	"routinist/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WellKnownHandler struct {
+	l logger.Interface
+}
+
+func NewWellKnownRoutes(handler *gin.RouterGroup, l logger.Interface) {
+	r := &WellKnownHandler{l}
+
+	h := handler.Group("/.well-known")
+	{
+		h.GET("/jwks.json", r.jwks)
+	}
+}
+
+// jwks publishes the public keys access tokens are signed with, so that other
+// services can verify them. It is served as a bare JWKS, as verifiers expect.
+func (h *WellKnownHandler) jwks(c *gin.Context) {
+	jwks, err := auth.PublicJWKS()
+	if err != nil {
+		h.l.Error(err)
+		r := response.Response{}
+		r.SetMessage("Something went wrong")
+		c.JSON(http.StatusInternalServerError, r)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,80 @@
+// Package conversion converts habit values between units of the same
+// measurement through a canonical base unit per measurement.
+package conversion
+
+import (
+	"routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/util"
+)
+
+// precision is the number of decimals converted values are rounded to, which
+// hides floating point noise such as 0.30000000000000004.
+const precision = 6
+
+// baseUnits is the canonical unit of each convertible measurement. Count units
+// (steps, pages, ...) measure different things and are their own base.
+var baseUnits = map[model.Measurement]string{
+	model.MeasurementTime:     "min",
+	model.MeasurementDistance: "m",
+	model.MeasurementWeight:   "g",
+	model.MeasurementVolume:   "l",
+}
+
+// factors is how many base units one unit is worth, keyed by unit symbol.
+var factors = map[string]float64{
+	"min": 1,
+	"h":   60,
+	"m":   1,
+	"km":  1000,
+	"g":   1,
+	"kg":  1000,
+	"lb":  453.59237,
+	"oz":  28.349523125,
+	"l":   1,
+}
+
+// BaseSymbol returns the symbol of the canonical unit values in u are
+// aggregated in.
+func BaseSymbol(u model.Unit) string {
+	if base, ok := baseUnits[u.Measurement]; ok {
+		if _, ok := factors[u.Symbol]; ok {
+			return base
+		}
+	}
+	return u.Symbol
+}
+
+// Compatible reports whether values can be converted between the two units.
+func Compatible(from, to model.Unit) bool {
+	if from.ID == to.ID {
+		return true
+	}
+	if from.Measurement != to.Measurement {
+		return false
+	}
+	_, okFrom := factors[from.Symbol]
+	_, okTo := factors[to.Symbol]
+	return okFrom && okTo && BaseSymbol(from) == BaseSymbol(to)
+}
+
+// Convert converts value from one unit to another.
+func Convert(value float64, from, to model.Unit) (float64, error) {
+	if from.ID == to.ID {
+		return value, nil
+	}
+	if !Compatible(from, to) {
+		return 0, errors.ErrIncompatibleUnits
+	}
+	return util.RoundFloat(value*factors[from.Symbol]/factors[to.Symbol], precision), nil
+}
+
+// ToBase converts value in u to the canonical unit of its measurement.
+func ToBase(value float64, u model.Unit) float64 {
+	if _, ok := baseUnits[u.Measurement]; ok {
+		if factor, ok := factors[u.Symbol]; ok {
+			return util.RoundFloat(value*factor, precision)
+		}
+	}
+	return value
+}
@@ -9,4 +9,48 @@ var (
 	ErrFailedToHashPassword = errors.New("failed to hash password")
 	ErrFailedToAddHabit     = errors.New("failed to add habit")
 	ErrUnauthorized         = errors.New("unauthorized")
+	ErrInvalidGoalFrequency = errors.New("invalid goal frequency")
+	ErrInvalidSchedule      = errors.New("invalid schedule")
+	ErrNoFreezeTokens       = errors.New("no freeze tokens left")
+	ErrInvalidProgressDate  = errors.New("invalid progress date")
+	ErrProgressCompleted    = errors.New("progress already completed")
+	ErrHabitNotFound        = errors.New("habit not found")
+	ErrHabitArchived        = errors.New("habit is archived")
+	ErrInvalidUnit          = errors.New("unit is not available for this habit")
+	ErrInvalidGoal          = errors.New("goal must be more than 0")
+	ErrInvalidMeasurement   = errors.New("invalid measurement")
+	ErrIncompatibleUnits    = errors.New("units measure different things")
+	ErrEntryNotFound        = errors.New("progress entry not found")
+	ErrInvalidEntrySource   = errors.New("invalid progress entry source")
+	ErrInvalidTimeZone      = errors.New("invalid time zone")
+	ErrInvalidRefreshToken  = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused   = errors.New("refresh token reused")
+	ErrSessionRevoked       = errors.New("session revoked")
+	ErrInvalidResetToken    = errors.New("invalid or expired password reset token")
+	ErrInvalidVerifyToken   = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+	ErrTooManyRequests      = errors.New("too many requests")
+	ErrInvalidGender        = errors.New("gender must be male, female or unspecified")
+	ErrInvalidName          = errors.New("name must not be empty")
+	ErrInvalidWeekStart     = errors.New("week start must be a day name such as monday")
+	ErrInvalidUnitSystem    = errors.New("unit system must be metric or imperial")
+	ErrUnknownProvider      = errors.New("unknown identity provider")
+	ErrInvalidIDToken       = errors.New("invalid ID token")
+	ErrEmailNotVerified     = errors.New("email address is not verified by the provider")
+	ErrReauthRequired       = errors.New("sign in with your identity provider again to confirm")
+	ErrPasswordAlreadySet   = errors.New("password already set")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired login challenge")
+	ErrReminderNotFound     = errors.New("reminder not found")
+	ErrInvalidReminderTime  = errors.New("reminder time must be a time of day such as 07:30")
+	ErrTooManyReminders     = errors.New("too many reminders for this habit")
+	ErrDeviceNotFound       = errors.New("device not found")
+	ErrInvalidPlatform      = errors.New("platform must be ios, android or web")
+	ErrInvalidDeviceToken   = errors.New("device token must not be empty")
+	ErrWebhookNotFound      = errors.New("webhook subscription not found")
+	ErrInvalidWebhookURL    = errors.New("webhook url must be an absolute http or https url")
+	ErrInvalidEventType     = errors.New("unknown event type")
+	ErrTooManyWebhooks      = errors.New("too many webhook subscriptions")
 )
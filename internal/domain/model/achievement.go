@@ -0,0 +1,53 @@
+package model
+
+import "time"
+
+// Achievement is a badge users unlock by meeting its rule. Achievements are
+// data: adding one only takes a new row, see seed.Seed.
+type Achievement struct {
+	ID          uint            `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Key         string          `gorm:"type:varchar(50);uniqueIndex;not null" json:"key"`
+	Name        string          `gorm:"type:varchar(100);not null" json:"name"`
+	Description string          `gorm:"type:varchar(255)" json:"description"`
+	Icon        string          `gorm:"type:varchar(20)" json:"icon"`
+	Rule        AchievementRule `gorm:"type:varchar(20);not null" json:"rule"`
+	Threshold   uint            `gorm:"not null;default:0" json:"threshold"`
+
+	// Frequency limits the rule to habits with this goal frequency, when set.
+	Frequency GoalFrequency `gorm:"type:varchar(10)" json:"frequency,omitempty"`
+}
+
+// AchievementRule is what an achievement's Threshold is measured against.
+type AchievementRule string
+
+const (
+	// RuleStreak unlocks with a streak of Threshold goal periods.
+	RuleStreak AchievementRule = "streak"
+	// RuleCompletions unlocks after Threshold goal periods were completed in
+	// total, across habits.
+	RuleCompletions AchievementRule = "completions"
+	// RulePerfectDay unlocks when every habit due on a day was done. It has
+	// no threshold.
+	RulePerfectDay AchievementRule = "perfect_day"
+)
+
+func (r AchievementRule) IsValid() bool {
+	switch r {
+	case RuleStreak, RuleCompletions, RulePerfectDay:
+		return true
+	}
+	return false
+}
+
+// UserAchievement is an achievement a user unlocked. It is never unlocked
+// twice, and stays unlocked when the progress behind it is later removed.
+type UserAchievement struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	UserID        uint      `gorm:"uniqueIndex:idx_user_achievement;not null" json:"user_id"`
+	AchievementID uint      `gorm:"uniqueIndex:idx_user_achievement;not null" json:"achievement_id"`
+	UnlockedAt    time.Time `gorm:"not null" json:"unlocked_at"`
+
+	Achievement Achievement `gorm:"foreignKey:AchievementID" json:"-"`
+}
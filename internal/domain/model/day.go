@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// CalendarDay is the calendar date of t in loc, expressed as midnight UTC.
+// Progress dates and streak periods are stored in this form, so a day means
+// the same thing whatever the time zone it was recorded in.
+func CalendarDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// ValidTimeZone reports whether name is an IANA time zone such as
+// "Asia/Jakarta".
+func ValidTimeZone(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
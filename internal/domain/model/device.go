@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// Device is a phone or browser a user receives push notifications on. Its
+// token is issued by the platform's push service and moves to whichever user
+// registered it last.
+type Device struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	UserID     uint      `gorm:"index;not null" json:"user_id"`
+	Token      string    `gorm:"type:varchar(512);uniqueIndex;not null" json:"-"`
+	Platform   Platform  `gorm:"type:varchar(10);not null" json:"platform"`
+	AppVersion string    `gorm:"type:varchar(32)" json:"app_version"`
+	LastSeenAt time.Time `gorm:"not null" json:"last_seen_at"`
+}
+
+type Platform string
+
+const (
+	PlatformIOS     Platform = "ios"
+	PlatformAndroid Platform = "android"
+	PlatformWeb     Platform = "web"
+)
+
+func (p Platform) IsValid() bool {
+	switch p {
+	case PlatformIOS, PlatformAndroid, PlatformWeb:
+		return true
+	}
+	return false
+}
+
+// PushDelivery records one attempt to deliver a notification to a device.
+type PushDelivery struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time      `gorm:"index" json:"created_at"`
+	UserID    uint           `gorm:"index;not null" json:"user_id"`
+	DeviceID  uint           `gorm:"index;not null" json:"device_id"`
+	Title     string         `json:"title"`
+	Status    DeliveryStatus `gorm:"type:varchar(16);not null" json:"status"`
+	Error     string         `json:"error,omitempty"`
+}
+
+type DeliveryStatus string
+
+const (
+	DeliverySent         DeliveryStatus = "sent"
+	DeliveryFailed       DeliveryStatus = "failed"
+	DeliveryInvalidToken DeliveryStatus = "invalid_token" // the device was pruned
+)
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// EmailVerificationToken proves that a user controls Email. It is sent at
+// signup and whenever a new address needs confirming. Only its SHA-256 hash
+// is stored and it can be used once.
+type EmailVerificationToken struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `gorm:"index" json:"created_at"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	Email     string     `gorm:"not null" json:"email"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// ExternalIdentity links a user to an account at an OpenID Connect provider,
+// identified by the provider's stable subject rather than the email, which
+// the user may change on either side.
+type ExternalIdentity struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	Provider  string    `gorm:"type:varchar(32);uniqueIndex:idx_external_identity_subject;not null" json:"provider"`
+	Subject   string    `gorm:"uniqueIndex:idx_external_identity_subject;not null" json:"subject"`
+	Email     string    `json:"email"` // as reported by the provider when linked
+}
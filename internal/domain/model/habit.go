@@ -11,4 +11,5 @@ type Habit struct {
 	Measurement Measurement `gorm:"type:varchar(20);not null" json:"measurement"`
 	Units       []Unit      `gorm:"many2many:habit_units;" json:"units"`
 	DefaultGoal float64     `gorm:"not null" json:"default_goal"`
+	OwnerID     *uint       `gorm:"index" json:"owner_id,omitempty"` // nil for catalog habits
 }
@@ -8,4 +8,23 @@ type HabitProgress struct {
 	Date        time.Time `gorm:"index:idx_userhabit_date,unique"`
 	Value       float64
 	IsCompleted bool
+	RestDay     bool           `gorm:"not null;default:false"` // logged on a day the habit was not scheduled
+	Status      ProgressStatus `gorm:"type:varchar(10);not null;default:'pending'"`
+
+	Entries []ProgressEntry `gorm:"foreignKey:HabitProgressID"`
+}
+
+// IsExcused reports whether the day was skipped or frozen. Excused days keep
+// streaks alive and are left out of success rates.
+func (p HabitProgress) IsExcused() bool {
+	return p.Status == ProgressStatusSkipped || p.Status == ProgressStatusFrozen
 }
+
+type ProgressStatus string
+
+const (
+	ProgressStatusPending   ProgressStatus = "pending"
+	ProgressStatusCompleted ProgressStatus = "completed"
+	ProgressStatusSkipped   ProgressStatus = "skipped"
+	ProgressStatusFrozen    ProgressStatus = "frozen"
+)
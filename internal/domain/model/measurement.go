@@ -9,3 +9,11 @@ const (
 	MeasurementDistance Measurement = "distance"
 	MeasurementWeight   Measurement = "weight"
 )
+
+func (m Measurement) IsValid() bool {
+	switch m {
+	case MeasurementVolume, MeasurementCount, MeasurementTime, MeasurementDistance, MeasurementWeight:
+		return true
+	}
+	return false
+}
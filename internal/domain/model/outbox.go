@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// OutboxEvent is a domain event waiting to be relayed to its subscribers. It
+// is written in the same transaction as the change it describes, so the
+// event exists if and only if the change was committed.
+type OutboxEvent struct {
+	ID            uint       `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time  `json:"created_at"`
+	EventID       string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"event_id"`
+	Type          string     `gorm:"type:varchar(32);not null" json:"type"`
+	UserID        uint       `gorm:"index;not null" json:"user_id"`
+	Payload       string     `gorm:"type:text;not null" json:"-"`
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt *time.Time `gorm:"index" json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	PublishedAt   *time.Time `gorm:"index" json:"published_at"`
+}
+
+// ProcessedEvent records that a subscriber handled an event, so that an event
+// relayed again is not handled twice by the same subscriber.
+type ProcessedEvent struct {
+	Consumer    string    `gorm:"type:varchar(32);primaryKey" json:"consumer"`
+	EventID     string    `gorm:"type:varchar(32);primaryKey" json:"event_id"`
+	ProcessedAt time.Time `gorm:"not null" json:"processed_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Preferences are the user's display and notification settings. The time
+// zone lives on the user itself.
+type Preferences struct {
+	WeekStart          time.Weekday `gorm:"default:0;not null" json:"week_start"`
+	UnitSystem         UnitSystem   `gorm:"type:varchar(10);default:'metric';not null" json:"unit_system"`
+	EmailNotifications bool         `gorm:"default:true;not null" json:"email_notifications"`
+	PushNotifications  bool         `gorm:"default:true;not null" json:"push_notifications"`
+}
+
+type UnitSystem string
+
+const (
+	UnitSystemMetric   UnitSystem = "metric"
+	UnitSystemImperial UnitSystem = "imperial"
+)
+
+func (s UnitSystem) IsValid() bool {
+	return s == UnitSystemMetric || s == UnitSystemImperial
+}
+
+// ParseWeekday reads a lowercase day name such as "monday".
+func ParseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToLower(d.String()) == name {
+			return d, true
+		}
+	}
+	return 0, false
+}
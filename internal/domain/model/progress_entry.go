@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// ProgressEntry is a single check-in. The value of a HabitProgress is the sum
+// of its entries.
+type ProgressEntry struct {
+	ID              uint        `gorm:"primaryKey;autoIncrement" json:"id"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+	HabitProgressID uint        `gorm:"not null;index" json:"habit_progress_id"`
+	UserHabitID     uint        `gorm:"not null;index" json:"user_habit_id"`
+	LoggedAt        time.Time   `gorm:"not null" json:"logged_at"`
+	Value           float64     `gorm:"not null" json:"value"`
+	Note            string      `json:"note"`
+	Source          EntrySource `gorm:"type:varchar(20);not null;default:'manual'" json:"source"`
+
+	// Date is the calendar day of the entry's progress, loaded with the entry
+	Date time.Time `gorm:"->;-:migration" json:"-"`
+}
+
+type EntrySource string
+
+const (
+	EntrySourceManual      EntrySource = "manual"
+	EntrySourceImport      EntrySource = "import"
+	EntrySourceIntegration EntrySource = "integration"
+	// EntrySourceLegacy holds a daily value logged before entries existed
+	EntrySourceLegacy EntrySource = "legacy"
+)
+
+// IsValid reports whether clients may log entries with the source.
+func (s EntrySource) IsValid() bool {
+	switch s {
+	case EntrySourceManual, EntrySourceImport, EntrySourceIntegration:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// RateLimitBucket counts recent failures for a rate limited key, such as an
+// account or an IP address trying to log in.
+type RateLimitBucket struct {
+	Key           string     `gorm:"primarykey;type:varchar(255)" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"index;not null" json:"last_failure_at"`
+	BlockedUntil  *time.Time `json:"blocked_until"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// RefreshToken is a long-lived token that can be exchanged for a new access
+// token. Only its SHA-256 hash is stored. Each use rotates it: the token is
+// revoked and replaced by a new one in the same family, so presenting a
+// revoked token again reveals that it was stolen.
+type RefreshToken struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UserID       uint       `gorm:"index;not null" json:"user_id"`
+	FamilyID     string     `gorm:"type:varchar(32);index;not null" json:"family_id"`
+	TokenHash    string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+	ReplacedByID *uint      `json:"replaced_by_id"`
+}
+
+// IsActive reports whether the token can still be used at now.
+func (t *RefreshToken) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
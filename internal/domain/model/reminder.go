@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// ReminderTimeLayout is the layout of Reminder.Time.
+const ReminderTimeLayout = "15:04"
+
+// Reminder nudges the user about a habit at a time of day in their own time
+// zone, on the days the habit is due.
+type Reminder struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	UserHabitID uint       `gorm:"index;not null" json:"user_habit_id"`
+	Time        string     `gorm:"column:time_of_day;type:varchar(5);not null" json:"time"` // local "15:04"
+	Enabled     bool       `gorm:"not null" json:"enabled"`
+	LastSentOn  *time.Time `json:"last_sent_on"` // calendar day of the last reminder sent
+	// NextDueAt is when the scheduler next looks at the reminder, in UTC
+	NextDueAt time.Time `gorm:"index;not null;default:CURRENT_TIMESTAMP" json:"-"`
+
+	UserHabit UserHabit `gorm:"foreignKey:UserHabitID" json:"-"`
+}
+
+// DueAt is the instant the reminder is due on a calendar day, in loc.
+func (r *Reminder) DueAt(day time.Time, loc *time.Location) time.Time {
+	t, err := time.Parse(ReminderTimeLayout, r.Time)
+	if err != nil {
+		return time.Time{}
+	}
+
+	y, m, d := day.UTC().Date()
+	return time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, loc)
+}
+
+// NextDue returns, in UTC, the first time after after that the reminder is due
+// in loc on a calendar day it was not sent yet.
+func (r *Reminder) NextDue(after time.Time, loc *time.Location) time.Time {
+	first := CalendarDay(after, loc).AddDate(0, 0, -1)
+	for i := 0; i < 4; i++ {
+		day := first.AddDate(0, 0, i)
+		if r.LastSentOn != nil && !r.LastSentOn.Before(day) {
+			continue
+		}
+
+		if dueAt := r.DueAt(day, loc); dueAt.After(after) {
+			return dueAt.UTC()
+		}
+	}
+
+	// Only an invalid time gets here; look at it again tomorrow
+	return after.Add(24 * time.Hour).UTC()
+}
+
+// ValidReminderTime reports whether s is a time of day such as "07:30".
+func ValidReminderTime(s string) bool {
+	_, err := time.Parse(ReminderTimeLayout, s)
+	return err == nil
+}
@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+type ScheduleType string
+
+const (
+	ScheduleEveryDay ScheduleType = "every_day"
+	ScheduleWeekdays ScheduleType = "weekdays"
+	ScheduleInterval ScheduleType = "interval"
+)
+
+func (t ScheduleType) IsValid() bool {
+	switch t {
+	case ScheduleEveryDay, ScheduleWeekdays, ScheduleInterval:
+		return true
+	}
+	return false
+}
+
+// Weekdays is a bitmask of time.Weekday values, bit 0 being Sunday.
+type Weekdays uint8
+
+// EveryWeekday has the bits of all seven days set.
+const EveryWeekday Weekdays = 0x7f
+
+// IsValid reports whether w has at least one day and no bits besides those of
+// the seven days.
+func (w Weekdays) IsValid() bool {
+	return w&^EveryWeekday == 0 && w&EveryWeekday != 0
+}
+
+func NewWeekdays(days ...time.Weekday) Weekdays {
+	var w Weekdays
+	for _, d := range days {
+		w |= 1 << uint(d)
+	}
+	return w
+}
+
+func (w Weekdays) Has(d time.Weekday) bool {
+	return w&(1<<uint(d)) != 0
+}
+
+func (w Weekdays) Days() []time.Weekday {
+	var days []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if w.Has(d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// Schedule decides on which calendar days a habit is due.
+type Schedule struct {
+	Type      ScheduleType `gorm:"type:varchar(10);default:'every_day'" json:"type"`
+	Weekdays  Weekdays     `gorm:"not null;default:0" json:"weekdays"`
+	Interval  uint         `gorm:"not null;default:0" json:"interval"`
+	StartDate time.Time    `json:"start_date"`
+}
+
+// IsDueOn reports whether the habit should be done on day, a calendar date
+// stored at UTC midnight.
+func (s Schedule) IsDueOn(day time.Time) bool {
+	switch s.Type {
+	case ScheduleWeekdays:
+		return s.Weekdays.Has(day.UTC().Weekday())
+	case ScheduleInterval:
+		if s.Interval <= 1 {
+			return true
+		}
+		start := s.StartDate.UTC().Truncate(24 * time.Hour)
+		d := day.UTC().Truncate(24 * time.Hour)
+		if d.Before(start) {
+			return false
+		}
+		days := int(d.Sub(start).Hours() / 24)
+		return days%int(s.Interval) == 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Streak is a run of consecutive completed goal periods of a user habit.
+// StartDate and EndDate are the starts of the first and last periods; EndDate
+// may be a skipped or frozen period that keeps the run alive.
+type Streak struct {
+	ID                uint      `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	UserHabitID       uint      `gorm:"not null;uniqueIndex:idx_streak_start" json:"user_habit_id"`
+	StartDate         time.Time `gorm:"not null;uniqueIndex:idx_streak_start" json:"start_date"`
+	EndDate           time.Time `gorm:"not null" json:"end_date"`
+	Length            uint      `gorm:"not null;default:0" json:"length"`
+	LastCompletedDate time.Time `json:"last_completed_date"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// TwoFactor is a user's TOTP authenticator. It is pending until the user
+// confirms it with a first code, and only then required at login.
+type TwoFactor struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	Secret    string     `gorm:"not null" json:"-"`
+	EnabledAt *time.Time `json:"enabled_at"`
+	LastStep  int64      `gorm:"not null;default:0" json:"-"` // time step of the last accepted code
+}
+
+func (tf *TwoFactor) IsEnabled() bool {
+	return tf != nil && tf.EnabledAt != nil
+}
+
+// RecoveryCode signs a user in once when they cannot use their
+// authenticator. Only its SHA-256 hash is stored.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+// LoginChallenge is handed out instead of tokens when a user with two-factor
+// authentication logs in, and exchanged for them with a valid code. Only its
+// SHA-256 hash is stored.
+type LoginChallenge struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	TokenHash string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	Attempts  int        `gorm:"not null;default:0" json:"attempts"`
+	UsedAt    *time.Time `json:"used_at"`
+}
@@ -5,15 +5,48 @@ import (
 )
 
 type User struct {
-	ID         uint        `gorm:"primarykey" json:"id"`
-	CreatedAt  time.Time   `json:"created_at"`
-	UpdatedAt  time.Time   `json:"updated_at"`
-	Email      string      `gorm:"unique;not null" json:"email"`
-	Password   string      `gorm:"not null" json:"-"`
-	Name       string      `gorm:"not null" json:"name"`
-	Gender     string      `gorm:"not null" json:"gender"`
-	UserHabits []UserHabit `gorm:"foreignKey:UserID"`
-	Milestone  uint        `json:"milestone" gorm:"default:0;not null"`
+	ID           uint        `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    time.Time   `json:"updated_at"`
+	Email        string      `gorm:"unique;not null" json:"email"`
+	Password     string      `gorm:"not null" json:"-"`
+	Name         string      `gorm:"not null" json:"name"`
+	Gender       Gender      `gorm:"not null" json:"gender"`
+	UserHabits   []UserHabit `gorm:"foreignKey:UserID"`
+	Milestone    uint        `json:"milestone" gorm:"default:0;not null"`
+	FreezeTokens uint        `json:"freeze_tokens" gorm:"default:0;not null"`
+	TimeZone     string      `json:"time_zone" gorm:"type:varchar(64);default:'UTC';not null"`
+	TokenVersion uint        `json:"-" gorm:"default:0;not null"`
+	VerifiedAt   *time.Time  `json:"verified_at"`
+	Preferences  Preferences `gorm:"embedded;embeddedPrefix:pref_" json:"preferences"`
+}
+
+// Location is the user's time zone, UTC when unset or unknown.
+func (u *User) Location() *time.Location {
+	loc, err := time.LoadLocation(u.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Today is the user's current calendar day.
+func (u *User) Today() time.Time {
+	return CalendarDay(time.Now(), u.Location())
+}
+
+// MilestonesPerFreezeToken is how many milestones earn one streak freeze token.
+const MilestonesPerFreezeToken = 10
+
+// MilestoneAward records the goal period a milestone was earned for. A period
+// earns at most one milestone, however often its total goes below the goal
+// and back.
+type MilestoneAward struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UserID      uint      `gorm:"index;not null" json:"user_id"`
+	UserHabitID uint      `gorm:"uniqueIndex:idx_milestone_award_period;not null" json:"user_habit_id"`
+	PeriodStart time.Time `gorm:"uniqueIndex:idx_milestone_award_period;not null" json:"period_start"`
 }
 
 type Gender string
@@ -23,3 +56,11 @@ const (
 	GenderFemale      Gender = "female"
 	GenderUnspecified Gender = "unspecified"
 )
+
+func (g Gender) IsValid() bool {
+	switch g {
+	case GenderMale, GenderFemale, GenderUnspecified:
+		return true
+	}
+	return false
+}
@@ -14,12 +14,35 @@ type UserHabit struct {
 	UnitID        uint          `gorm:"not null" json:"unit_id"`
 	Goal          float64       `gorm:"not null" json:"goal"`
 	GoalFrequency GoalFrequency `gorm:"type:varchar(10);default:'daily'" json:"goal_frequency"`
+	Schedule      Schedule      `gorm:"embedded;embeddedPrefix:schedule_" json:"schedule"`
+	ArchivedAt    *time.Time    `gorm:"index" json:"archived_at"`
 
 	User  User  `gorm:"foreignKey:UserID"`
 	Habit Habit `gorm:"foreignKey:HabitID"`
 	Unit  Unit  `gorm:"foreignKey:UnitID"`
 }
 
+// PeriodRange returns the bounds of the goal period containing day, with
+// weeks starting on the day the user prefers. User must be loaded.
+func (uh *UserHabit) PeriodRange(day time.Time) (time.Time, time.Time) {
+	return uh.GoalFrequency.PeriodRange(day, uh.User.Preferences.WeekStart)
+}
+
+// NextDuePeriod returns the start of the first goal period after period in
+// which the habit is due. Weekly and monthly periods are always due.
+func (uh *UserHabit) NextDuePeriod(period time.Time) time.Time {
+	_, next := uh.PeriodRange(period)
+	if uh.GoalFrequency == FrequencyWeekly || uh.GoalFrequency == FrequencyMonthly {
+		return next
+	}
+
+	for i := 0; i < 366 && !uh.Schedule.IsDueOn(next); i++ {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
+
 type GoalFrequency string
 
 const (
@@ -27,3 +50,42 @@ const (
 	FrequencyWeekly  GoalFrequency = "weekly"
 	FrequencyMonthly GoalFrequency = "monthly"
 )
+
+func (f GoalFrequency) IsValid() bool {
+	switch f {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly:
+		return true
+	}
+	return false
+}
+
+// PeriodRange returns the [from, to) bounds of the goal period containing day.
+// Progress dates are calendar days stored at UTC midnight, so the bounds are too.
+// Weeks start on weekStart.
+func (f GoalFrequency) PeriodRange(day time.Time, weekStart time.Weekday) (time.Time, time.Time) {
+	y, m, d := day.UTC().Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	switch f {
+	case FrequencyWeekly:
+		from := start.AddDate(0, 0, -(int(start.Weekday()-weekStart)+7)%7)
+		return from, from.AddDate(0, 0, 7)
+	case FrequencyMonthly:
+		from := time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+		return from, from.AddDate(0, 1, 0)
+	default:
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// PeriodLabel is the human-readable name of the current goal period.
+func (f GoalFrequency) PeriodLabel() string {
+	switch f {
+	case FrequencyWeekly:
+		return "this week"
+	case FrequencyMonthly:
+		return "this month"
+	default:
+		return "today"
+	}
+}
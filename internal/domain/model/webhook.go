@@ -0,0 +1,51 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// WebhookSubscription sends a user's events of the given types to a URL.
+// Deliveries are signed with Secret.
+type WebhookSubscription struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"index;not null" json:"user_id"`
+	URL       string    `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret    string    `gorm:"not null" json:"-"`
+	Events    string    `gorm:"not null" json:"-"` // comma-separated event types
+}
+
+func (s *WebhookSubscription) EventTypes() []string {
+	return strings.Split(s.Events, ",")
+}
+
+func (s *WebhookSubscription) Wants(eventType string) bool {
+	for _, t := range s.EventTypes() {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event on its way to a subscription, retried with a
+// growing delay until the subscriber accepts it or we give up.
+type WebhookDelivery struct {
+	ID             uint           `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time      `json:"created_at"`
+	SubscriptionID uint           `gorm:"uniqueIndex:idx_webhook_delivery_event;not null" json:"subscription_id"`
+	EventID        string         `gorm:"type:varchar(32);uniqueIndex:idx_webhook_delivery_event;not null" json:"event_id"`
+	EventType      string         `gorm:"type:varchar(32);not null" json:"event_type"`
+	Payload        string         `gorm:"type:text;not null" json:"-"`
+	Status         DeliveryStatus `gorm:"type:varchar(16);index;not null" json:"status"`
+	Attempts       int            `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time     `gorm:"index" json:"next_attempt_at"`
+	LastStatusCode int            `json:"last_status_code"`
+	LastError      string         `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at"`
+
+	Subscription WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+}
+
+const DeliveryPending DeliveryStatus = "pending"
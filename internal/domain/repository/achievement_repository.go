@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type AchievementRepository interface {
+	GetAchievements() ([]model.Achievement, error)
+	GetUserAchievements(userId uint) ([]model.UserAchievement, error)
+	UnlockAchievement(db *gorm.DB, userId uint, achievementId uint, at time.Time) (bool, error)
+	GetCompletedPeriods(userId uint, frequency model.GoalFrequency) (uint, error)
+	GetLongestStreak(userId uint, frequency model.GoalFrequency) (uint, error)
+	GetDB() *gorm.DB
+}
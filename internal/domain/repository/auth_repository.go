@@ -2,11 +2,45 @@ package repository
 
 import (
 	"gorm.io/gorm"
+	"routinist/internal/domain/model"
 	"routinist/internal/dto/request"
+	"time"
 )
 
 type AuthRepository interface {
-	Register(db *gorm.DB, e *request.RegisterRequestDTO) (*request.AuthResponseDTO, uint, error)
-	Login(e *request.LoginRequestDTO) (*request.AuthResponseDTO, uint, error)
+	Register(db *gorm.DB, e *request.RegisterRequestDTO) (*model.User, error)
+	Login(e *request.LoginRequestDTO) (*model.User, error)
+	CreateRefreshToken(db *gorm.DB, token *model.RefreshToken) error
+	GetRefreshToken(tokenHash string) (*model.RefreshToken, error)
+	RotateRefreshToken(db *gorm.DB, old *model.RefreshToken, next *model.RefreshToken) error
+	RevokeRefreshTokenFamily(familyId string) error
+	RevokeUserRefreshTokens(db *gorm.DB, userId uint, keepSessionId string) error
+	CheckPassword(userId uint, password string) error
+	IsSessionActive(sessionId string) (bool, error)
+	GetUserByEmail(email string) (*model.User, error)
+	UpdatePassword(db *gorm.DB, userId uint, password string) error
+	CreatePasswordResetToken(token *model.PasswordResetToken) error
+	GetPasswordResetToken(tokenHash string) (*model.PasswordResetToken, error)
+	UsePasswordResetToken(db *gorm.DB, token *model.PasswordResetToken) error
+	CreateEmailVerificationToken(token *model.EmailVerificationToken) error
+	GetEmailVerificationToken(tokenHash string) (*model.EmailVerificationToken, error)
+	CountEmailVerificationTokens(userId uint, since time.Time) (int64, error)
+	UseEmailVerificationToken(db *gorm.DB, token *model.EmailVerificationToken) error
+	MarkEmailVerified(db *gorm.DB, userId uint, email string) error
+	RegisterExternal(db *gorm.DB, email string, name string, timeZone string) (*model.User, error)
+	ClearPassword(db *gorm.DB, userId uint) error
+	GetExternalIdentity(provider string, subject string) (*model.ExternalIdentity, error)
+	CreateExternalIdentity(db *gorm.DB, identity *model.ExternalIdentity) error
+	GetTwoFactor(userId uint) (*model.TwoFactor, error)
+	CreateTwoFactor(db *gorm.DB, twoFactor *model.TwoFactor) error
+	EnableTwoFactor(db *gorm.DB, userId uint, step int64) error
+	UseTOTPStep(userId uint, step int64) error
+	DeleteTwoFactor(db *gorm.DB, userId uint) error
+	ReplaceRecoveryCodes(db *gorm.DB, userId uint, codeHashes []string) error
+	UseRecoveryCode(userId uint, codeHash string) error
+	CreateLoginChallenge(challenge *model.LoginChallenge) error
+	GetLoginChallenge(tokenHash string) (*model.LoginChallenge, error)
+	RecordChallengeAttempt(challengeId uint, maxAttempts int) error
+	UseLoginChallenge(challengeId uint) error
 	GetDB() *gorm.DB
 }
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"routinist/internal/domain/model"
+)
+
+type DeviceRepository interface {
+	RegisterDevice(device *model.Device) error
+	GetDevices(userId uint) ([]model.Device, error)
+	GetDevice(userId uint, deviceId uint) (*model.Device, error)
+	DeleteDevice(deviceId uint) error
+	RecordDelivery(delivery *model.PushDelivery) error
+}
@@ -7,18 +7,32 @@ import (
 )
 
 type HabitRepository interface {
-	CreateUserHabit(db *gorm.DB, userId uint, habitId uint, unitId *uint, goal *float64) (*model.UserHabit, error)
+	CreateUserHabit(db *gorm.DB, userId uint, habitId uint, unitId *uint, goal *float64, frequency model.GoalFrequency, schedule model.Schedule) (*model.UserHabit, error)
+	CreateCustomHabit(db *gorm.DB, userId uint, name string, icon string, measurement model.Measurement, unitIds []uint, defaultGoal float64) (*model.Habit, error)
+	GetCustomHabits(userId uint) ([]model.Habit, error)
+	GetUnits() ([]model.Unit, error)
+	GetUnit(unitId uint) (*model.Unit, error)
 	GetRandomHabits() (*[]model.Habit, error)
-	GetTodayHabits(userId uint) ([]model.UserHabit, error)
+	GetTodayHabits(userId uint, today time.Time) ([]model.UserHabit, error)
 	GetUserHabit(userId uint, userHabitId uint) (*model.UserHabit, error)
-	GetUserHabits(userId uint) ([]*model.UserHabit, error)
-	CreateProgress(userHabitId uint, value float64) (*model.HabitProgress, error)
-	UpdateProgress(progressId uint, value float64) (*model.HabitProgress, error)
+	GetUserHabits(userId uint, includeArchived bool) ([]*model.UserHabit, error)
+	UpdateUserHabit(db *gorm.DB, uh *model.UserHabit) error
+	ConvertProgressValues(db *gorm.DB, userHabitId uint, factor float64) error
+	SetUserHabitArchived(userHabitId uint, archivedAt *time.Time) error
+	DeleteUserHabit(db *gorm.DB, userHabitId uint) error
+	CreateProgress(db *gorm.DB, userHabitId uint, day time.Time, value float64, note string, source model.EntrySource) (*model.HabitProgress, error)
+	GetProgressEntries(userHabitId uint, day time.Time) (*model.HabitProgress, error)
+	GetProgressEntry(userHabitId uint, entryId uint) (*model.ProgressEntry, error)
+	UpdateProgressEntry(db *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error)
+	DeleteProgressEntry(db *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error)
 	GetProgress(userHabitId uint) (float64, error)
-	GetProgressSummary(userHabitID uint, from, to time.Time) (completed int64, total int64, err error)
-	EnsureTodayProgressForUser(userId uint) error
-	GetTodayHabitProgress(userHabitId uint) (*model.HabitProgress, error)
-	GetTodayHabitProgresses(userHabitId []uint) ([]model.HabitProgress, error)
+	GetProgressByDate(userHabitId uint, day time.Time) (*model.HabitProgress, error)
+	GetPeriodProgress(db *gorm.DB, userHabitId uint, from, to time.Time) (float64, error)
+	RecalculateCompletion(db *gorm.DB, userHabitId uint, day time.Time) (*model.HabitProgress, error)
+	ExcuseProgress(db *gorm.DB, userHabitId uint, day time.Time, status model.ProgressStatus) (*model.HabitProgress, error)
+	EnsureTodayProgressForUser(userId uint, today time.Time) error
+	GetTodayHabitProgress(userHabitId uint, today time.Time) (*model.HabitProgress, error)
+	GetTodayHabitProgresses(userHabitId []uint, today time.Time) ([]model.HabitProgress, error)
 	GetUserHabitProgresses(userId uint, userHabitId uint, from, to time.Time) ([]model.HabitProgress, error)
 	GetDB() *gorm.DB
 }
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type OutboxRepository interface {
+	AppendEvents(db *gorm.DB, events []model.OutboxEvent) error
+	GetDueEvents(now time.Time, limit int) ([]model.OutboxEvent, error)
+	ClaimEvent(event *model.OutboxEvent, until time.Time) (bool, error)
+	UpdateEvent(event *model.OutboxEvent) error
+	GetProcessedConsumers(eventId string) ([]string, error)
+	MarkProcessed(consumer string, eventId string) error
+	RecordProcessed(db *gorm.DB, consumer string, eventId string) (bool, error)
+	GetDB() *gorm.DB
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type ReminderRepository interface {
+	GetReminders(userHabitId uint) ([]model.Reminder, error)
+	GetReminder(userHabitId uint, reminderId uint) (*model.Reminder, error)
+	CreateReminder(reminder *model.Reminder) error
+	UpdateReminder(reminder *model.Reminder) error
+	DeleteReminder(reminder *model.Reminder) error
+	GetDueReminders(now time.Time, limit int) ([]model.Reminder, error)
+	SetNextDueAt(reminderId uint, nextDueAt time.Time) error
+	ClaimReminder(reminderId uint, day time.Time, nextDueAt time.Time) (bool, error)
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"routinist/internal/domain/model"
+)
+
+type StreakRepository interface {
+	GetLatestStreak(db *gorm.DB, userHabitId uint) (*model.Streak, error)
+	GetLatestStreaks(userHabitIds []uint) (map[uint]model.Streak, error)
+	GetLongestStreaks(userHabitIds []uint) (map[uint]uint, error)
+	GetStreaks(userHabitId uint) ([]model.Streak, error)
+	SaveStreak(db *gorm.DB, streak *model.Streak) error
+	ReplaceStreaks(db *gorm.DB, userHabitId uint, streaks []model.Streak) error
+	DeleteStreaks(db *gorm.DB, userHabitId uint) error
+}
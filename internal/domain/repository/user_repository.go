@@ -1,5 +1,18 @@
 package repository
 
+import (
+	"gorm.io/gorm"
+	"routinist/internal/domain/model"
+	"time"
+)
+
 type UserRepository interface {
-	UpdateMilestone(userId uint, milestone uint) (uint, error)
+	GetUser(userId uint) (*model.User, error)
+	UpdateTimeZone(userId uint, timeZone string) error
+	RescheduleReminders(userId uint) error
+	UpdateUser(user *model.User) error
+	AwardMilestone(db *gorm.DB, userId uint, userHabitId uint, period time.Time) (uint, error)
+	UseFreezeToken(db *gorm.DB, userId uint) (uint, error)
+	IncrementTokenVersion(db *gorm.DB, userId uint) error
+	DeleteUser(db *gorm.DB, userId uint) error
 }
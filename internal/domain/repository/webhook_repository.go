@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(subscription *model.WebhookSubscription) error
+	GetSubscriptions(userId uint) ([]model.WebhookSubscription, error)
+	GetSubscription(userId uint, subscriptionId uint) (*model.WebhookSubscription, error)
+	DeleteSubscription(db *gorm.DB, subscriptionId uint) error
+	CreateDeliveries(db *gorm.DB, deliveries []model.WebhookDelivery) error
+	GetDeliveries(subscriptionId uint, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error)
+	GetDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error)
+	ClaimDelivery(delivery *model.WebhookDelivery, until time.Time) (bool, error)
+	UpdateDelivery(delivery *model.WebhookDelivery) error
+	GetDB() *gorm.DB
+}
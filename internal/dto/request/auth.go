@@ -6,6 +6,7 @@ type RegisterRequestDTO struct {
 	Name     string `json:"name"`
 	Gender   string `json:"gender"`
 	HabitID  uint   `json:"habit_id"`
+	TimeZone string `json:"time_zone"` // IANA name, defaults to UTC
 }
 
 type LoginRequestDTO struct {
@@ -13,6 +14,73 @@ type LoginRequestDTO struct {
 	Password string `json:"password"`
 }
 
-type AuthResponseDTO struct {
+type PasswordResetRequestDTO struct {
+	Email string `json:"email"`
+}
+
+type ConfirmPasswordResetRequestDTO struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type ConfirmEmailVerificationRequestDTO struct {
 	Token string `json:"token"`
 }
+
+// ReauthRequestDTO confirms a sensitive account change. Accounts created
+// through an identity provider have no password and send a fresh ID token
+// from that provider instead.
+type ReauthRequestDTO struct {
+	Password string `json:"password"`
+	Provider string `json:"provider"`
+	IDToken  string `json:"id_token"`
+}
+
+type ChangePasswordRequestDTO struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+	Provider        string `json:"provider"`
+	IDToken         string `json:"id_token"`
+}
+
+// SetPasswordRequestDTO adds a password to an account that has none,
+// confirmed with a fresh ID token from a linked provider.
+type SetPasswordRequestDTO struct {
+	Provider string `json:"provider"`
+	IDToken  string `json:"id_token"`
+	Password string `json:"password"`
+}
+
+type ChangeEmailRequestDTO struct {
+	Email string `json:"email"`
+	ReauthRequestDTO
+}
+
+type DeleteAccountRequestDTO struct {
+	ReauthRequestDTO
+}
+
+type RefreshTokenRequestDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AuthResponseDTO holds the tokens of a new session. When the user has
+// two-factor authentication, login returns a challenge to answer with a code
+// instead.
+type AuthResponseDTO struct {
+	Token             string `json:"token,omitempty"`
+	RefreshToken      string `json:"refresh_token,omitempty"`
+	ExpiresIn         int64  `json:"expires_in,omitempty"` // seconds until the access token expires
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
+}
+
+// ProviderLoginRequestDTO signs in with an ID token from an OpenID Connect
+// provider. Name, TimeZone and HabitID are only used when a new account is
+// created.
+type ProviderLoginRequestDTO struct {
+	IDToken  string `json:"id_token"`
+	Name     string `json:"name"`
+	TimeZone string `json:"time_zone"`
+	HabitID  uint   `json:"habit_id"`
+}
@@ -0,0 +1,9 @@
+package request
+
+type CreateCustomHabitRequestDTO struct {
+	Name        string  `json:"name"`
+	Icon        string  `json:"icon"`
+	Measurement string  `json:"measurement"`
+	UnitIds     []uint  `json:"unit_ids"`
+	DefaultGoal float64 `json:"default_goal"`
+}
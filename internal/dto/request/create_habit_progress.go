@@ -1,5 +1,16 @@
 package request
 
 type CreateHabitProgressRequestDTO struct {
-	Value float64 `json:"progress"`
+	Value  float64 `json:"progress"`
+	UnitId uint    `json:"unit_id"` // defaults to the habit's unit
+	Note   string  `json:"note"`
+	Source string  `json:"source"` // manual, import or integration; defaults to manual
+	Date   string  `json:"date"`   // YYYY-MM-DD, defaults to today
+}
+
+// UpdateProgressEntryRequestDTO edits a progress entry. Omitted fields are left
+// unchanged.
+type UpdateProgressEntryRequestDTO struct {
+	Value *float64 `json:"progress"`
+	Note  *string  `json:"note"`
 }
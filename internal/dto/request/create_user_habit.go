@@ -1,7 +1,9 @@
 package request
 
 type CreateUserHabitRequestDTO struct {
-	UnitId  uint    `json:"unit_id"`
-	HabitId uint    `json:"habit_id"`
-	Goal    float64 `json:"goal"`
+	UnitId        uint                `json:"unit_id"`
+	HabitId       uint                `json:"habit_id"`
+	Goal          float64             `json:"goal"`
+	GoalFrequency string              `json:"goal_frequency"`
+	Schedule      *ScheduleRequestDTO `json:"schedule"`
 }
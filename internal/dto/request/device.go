@@ -0,0 +1,7 @@
+package request
+
+type RegisterDeviceRequestDTO struct {
+	Token      string `json:"token"`
+	Platform   string `json:"platform"` // ios, android or web
+	AppVersion string `json:"app_version"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// ExcuseProgressRequestDTO marks a day as skipped or frozen. Date is formatted
+// YYYY-MM-DD and defaults to today.
+type ExcuseProgressRequestDTO struct {
+	Date string `json:"date"`
+}
@@ -0,0 +1,11 @@
+package request
+
+type CreateReminderRequestDTO struct {
+	Time    string `json:"time"` // local time of day, "07:30"
+	Enabled *bool  `json:"enabled"`
+}
+
+type UpdateReminderRequestDTO struct {
+	Time    *string `json:"time"`
+	Enabled *bool   `json:"enabled"`
+}
@@ -0,0 +1,10 @@
+package request
+
+// ScheduleRequestDTO describes on which days a habit is due. Weekdays are
+// numbered from 0 (Sunday) to 6 (Saturday); StartDate is formatted YYYY-MM-DD.
+type ScheduleRequestDTO struct {
+	Type      string `json:"type"`
+	Weekdays  []int  `json:"weekdays"`
+	Interval  uint   `json:"interval"`
+	StartDate string `json:"start_date"`
+}
@@ -0,0 +1,21 @@
+package request
+
+type TwoFactorCodeRequestDTO struct {
+	Code string `json:"code"` // TOTP code or, where accepted, a recovery code
+}
+
+type TwoFactorChallengeRequestDTO struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"` // TOTP code or recovery code
+}
+
+// TwoFactorEnrollmentDTO is what an authenticator app needs to enroll: the
+// secret to type in, or the URI to scan as a QR code.
+type TwoFactorEnrollmentDTO struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
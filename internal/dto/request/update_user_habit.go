@@ -0,0 +1,10 @@
+package request
+
+// UpdateUserHabitRequestDTO changes the settings of a user habit. Omitted
+// fields are left unchanged.
+type UpdateUserHabitRequestDTO struct {
+	UnitId        *uint               `json:"unit_id"`
+	Goal          *float64            `json:"goal"`
+	GoalFrequency *string             `json:"goal_frequency"`
+	Schedule      *ScheduleRequestDTO `json:"schedule"`
+}
@@ -0,0 +1,25 @@
+package request
+
+type UpdateTimeZoneRequestDTO struct {
+	TimeZone string `json:"time_zone"`
+}
+
+// UpdateUserRequestDTO edits the user's profile. Omitted fields are left
+// unchanged.
+type UpdateUserRequestDTO struct {
+	Name        *string                      `json:"name"`
+	Gender      *string                      `json:"gender"` // male, female or unspecified
+	Preferences *UpdatePreferencesRequestDTO `json:"preferences"`
+}
+
+type UpdatePreferencesRequestDTO struct {
+	TimeZone      *string                                  `json:"time_zone"`  // IANA name
+	WeekStart     *string                                  `json:"week_start"` // day name such as monday
+	UnitSystem    *string                                  `json:"unit_system"`
+	Notifications *UpdateNotificationPreferencesRequestDTO `json:"notifications"`
+}
+
+type UpdateNotificationPreferencesRequestDTO struct {
+	Email *bool `json:"email"`
+	Push  *bool `json:"push"`
+}
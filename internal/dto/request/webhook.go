@@ -0,0 +1,6 @@
+package request
+
+type CreateWebhookRequestDTO struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"` // event types to send, all when empty
+}
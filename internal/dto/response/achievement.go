@@ -0,0 +1,34 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type AchievementDto struct {
+	Key         string                `json:"key"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Icon        string                `json:"icon"`
+	Rule        model.AchievementRule `json:"rule"`
+	Threshold   uint                  `json:"threshold"`
+	Frequency   model.GoalFrequency   `json:"frequency,omitempty"`
+	Unlocked    bool                  `json:"unlocked"`
+	UnlockedAt  *time.Time            `json:"unlocked_at"`
+}
+
+// ToAchievementDto describes an achievement to a user, with when they
+// unlocked it or nil when they did not yet.
+func ToAchievementDto(a *model.Achievement, unlockedAt *time.Time) AchievementDto {
+	return AchievementDto{
+		Key:         a.Key,
+		Name:        a.Name,
+		Description: a.Description,
+		Icon:        a.Icon,
+		Rule:        a.Rule,
+		Threshold:   a.Threshold,
+		Frequency:   a.Frequency,
+		Unlocked:    unlockedAt != nil,
+		UnlockedAt:  unlockedAt,
+	}
+}
@@ -1,10 +1,20 @@
 package response
 
+import "routinist/internal/domain/model"
+
 type ActivitySummaryDto struct {
-	SuccessRate   float64 `json:"success_rate"`
-	Completed     uint    `json:"completed"`
-	Failed        uint    `json:"failed"`
-	UserHabitId   uint    `json:"user_habit_id"`
-	UserHabitName string  `json:"user_habit_name"`
-	UserHabitIcon string  `json:"user_habit_icon"`
+	SuccessRate   float64         `json:"success_rate"`
+	Completed     uint            `json:"completed"`
+	Failed        uint            `json:"failed"`
+	UserHabitId   uint            `json:"user_habit_id"`
+	UserHabitName string          `json:"user_habit_name"`
+	UserHabitIcon string          `json:"user_habit_icon"`
+	Totals        []ValueTotalDto `json:"totals"`
+}
+
+// ValueTotalDto is the progress logged for one measurement, in its base unit.
+type ValueTotalDto struct {
+	Measurement model.Measurement `json:"measurement"`
+	Unit        string            `json:"unit"`
+	Value       float64           `json:"value"`
 }
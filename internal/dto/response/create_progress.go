@@ -1,5 +1,6 @@
 package response
 
 type CreateProgressDto struct {
-	Milestone uint `json:"milestone"`
+	Milestone uint              `json:"milestone"`
+	Progress  *DailyProgressDto `json:"progress,omitempty"`
 }
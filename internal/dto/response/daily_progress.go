@@ -0,0 +1,50 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type ProgressEntryDto struct {
+	ID       uint              `json:"id"`
+	LoggedAt time.Time         `json:"logged_at"`
+	Value    float64           `json:"value"`
+	Note     string            `json:"note"`
+	Source   model.EntrySource `json:"source"`
+}
+
+// DailyProgressDto is the progress of a user habit on one day with the entries
+// it adds up from.
+type DailyProgressDto struct {
+	UserHabitId uint                 `json:"user_habit_id"`
+	Date        time.Time            `json:"date"`
+	Value       float64              `json:"value"`
+	IsCompleted bool                 `json:"is_completed"`
+	Status      model.ProgressStatus `json:"status"`
+	Entries     []ProgressEntryDto   `json:"entries"`
+
+	// Milestone is the user's new milestone when an entry change earned one.
+	Milestone uint `json:"milestone,omitempty"`
+}
+
+func ToDailyProgressDto(p *model.HabitProgress) DailyProgressDto {
+	entries := make([]ProgressEntryDto, len(p.Entries))
+	for i, e := range p.Entries {
+		entries[i] = ProgressEntryDto{
+			ID:       e.ID,
+			LoggedAt: e.LoggedAt,
+			Value:    e.Value,
+			Note:     e.Note,
+			Source:   e.Source,
+		}
+	}
+
+	return DailyProgressDto{
+		UserHabitId: p.UserHabitID,
+		Date:        p.Date,
+		Value:       p.Value,
+		IsCompleted: p.IsCompleted,
+		Status:      p.Status,
+		Entries:     entries,
+	}
+}
@@ -0,0 +1,24 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type DeviceDto struct {
+	ID         uint           `json:"id"`
+	Platform   model.Platform `json:"platform"`
+	AppVersion string         `json:"app_version"`
+	LastSeenAt time.Time      `json:"last_seen_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+func ToDeviceDto(d *model.Device) DeviceDto {
+	return DeviceDto{
+		ID:         d.ID,
+		Platform:   d.Platform,
+		AppVersion: d.AppVersion,
+		LastSeenAt: d.LastSeenAt,
+		CreatedAt:  d.CreatedAt,
+	}
+}
@@ -0,0 +1,13 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type ExcuseProgressDto struct {
+	Date         time.Time            `json:"date"`
+	Status       model.ProgressStatus `json:"status"`
+	Streak       StreakDto            `json:"streak"`
+	FreezeTokens *uint                `json:"freeze_tokens,omitempty"`
+}
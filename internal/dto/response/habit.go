@@ -10,6 +10,7 @@ type HabitDto struct {
 	Units       []UnitDto         `json:"units"`
 	DefaultGoal float64           `json:"default_goal"`
 	Color       float64           `json:"color"`
+	IsCustom    bool              `json:"is_custom"`
 }
 
 func ToHabitDto(h model.Habit, color float64) HabitDto {
@@ -25,5 +26,6 @@ func ToHabitDto(h model.Habit, color float64) HabitDto {
 		Units:       units,
 		DefaultGoal: h.DefaultGoal,
 		Color:       color,
+		IsCustom:    h.OwnerID != nil,
 	}
 }
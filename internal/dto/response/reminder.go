@@ -0,0 +1,24 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type ReminderDto struct {
+	ID          uint       `json:"id"`
+	UserHabitID uint       `json:"user_habit_id"`
+	Time        string     `json:"time"`
+	Enabled     bool       `json:"enabled"`
+	LastSentOn  *time.Time `json:"last_sent_on"`
+}
+
+func ToReminderDto(r *model.Reminder) ReminderDto {
+	return ReminderDto{
+		ID:          r.ID,
+		UserHabitID: r.UserHabitID,
+		Time:        r.Time,
+		Enabled:     r.Enabled,
+		LastSentOn:  r.LastSentOn,
+	}
+}
@@ -0,0 +1,33 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type ScheduleDto struct {
+	Type      model.ScheduleType `json:"type"`
+	Weekdays  []int              `json:"weekdays,omitempty"`
+	Interval  uint               `json:"interval,omitempty"`
+	StartDate *time.Time         `json:"start_date,omitempty"`
+}
+
+func toScheduleDto(s model.Schedule) ScheduleDto {
+	dto := ScheduleDto{Type: s.Type}
+	if dto.Type == "" {
+		dto.Type = model.ScheduleEveryDay
+	}
+
+	switch s.Type {
+	case model.ScheduleWeekdays:
+		for _, d := range s.Weekdays.Days() {
+			dto.Weekdays = append(dto.Weekdays, int(d))
+		}
+	case model.ScheduleInterval:
+		start := s.StartDate
+		dto.Interval = s.Interval
+		dto.StartDate = &start
+	}
+
+	return dto
+}
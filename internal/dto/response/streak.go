@@ -0,0 +1,55 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type StreakDto struct {
+	Current           uint       `json:"current"`
+	Longest           uint       `json:"longest"`
+	LastCompletedDate *time.Time `json:"last_completed_date"`
+}
+
+type StreakRunDto struct {
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Length    uint      `json:"length"`
+}
+
+type StreakHistoryDto struct {
+	UserHabitId uint           `json:"user_habit_id"`
+	Streak      StreakDto      `json:"streak"`
+	History     []StreakRunDto `json:"history"`
+}
+
+func ToStreakDto(current, longest uint, latest *model.Streak) StreakDto {
+	dto := StreakDto{
+		Current: current,
+		Longest: longest,
+	}
+
+	if latest != nil {
+		lastCompleted := latest.LastCompletedDate
+		dto.LastCompletedDate = &lastCompleted
+	}
+
+	return dto
+}
+
+func ToStreakHistoryDto(userHabitId uint, streak StreakDto, runs []model.Streak) StreakHistoryDto {
+	history := make([]StreakRunDto, len(runs))
+	for i, s := range runs {
+		history[i] = StreakRunDto{
+			StartDate: s.StartDate,
+			EndDate:   s.EndDate,
+			Length:    s.Length,
+		}
+	}
+
+	return StreakHistoryDto{
+		UserHabitId: userHabitId,
+		Streak:      streak,
+		History:     history,
+	}
+}
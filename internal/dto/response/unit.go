@@ -17,3 +17,11 @@ func toUnitDto(u model.Unit) UnitDto {
 		Measurement: u.Measurement,
 	}
 }
+
+func ToUnitDtos(units []model.Unit) []UnitDto {
+	result := make([]UnitDto, len(units))
+	for i, u := range units {
+		result[i] = toUnitDto(u)
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"strings"
+	"time"
+)
+
+type UserDto struct {
+	ID           uint           `json:"id"`
+	Email        string         `json:"email"`
+	Name         string         `json:"name"`
+	Gender       model.Gender   `json:"gender"`
+	Milestone    uint           `json:"milestone"`
+	FreezeTokens uint           `json:"freeze_tokens"`
+	VerifiedAt   *time.Time     `json:"verified_at"`
+	CreatedAt    time.Time      `json:"created_at"`
+	Preferences  PreferencesDto `json:"preferences"`
+}
+
+type PreferencesDto struct {
+	TimeZone      string                     `json:"time_zone"`
+	WeekStart     string                     `json:"week_start"`
+	UnitSystem    model.UnitSystem           `json:"unit_system"`
+	Notifications NotificationPreferencesDto `json:"notifications"`
+}
+
+type NotificationPreferencesDto struct {
+	Email bool `json:"email"`
+	Push  bool `json:"push"`
+}
+
+func ToUserDto(u *model.User) UserDto {
+	return UserDto{
+		ID:           u.ID,
+		Email:        u.Email,
+		Name:         u.Name,
+		Gender:       u.Gender,
+		Milestone:    u.Milestone,
+		FreezeTokens: u.FreezeTokens,
+		VerifiedAt:   u.VerifiedAt,
+		CreatedAt:    u.CreatedAt,
+		Preferences: PreferencesDto{
+			TimeZone:   u.TimeZone,
+			WeekStart:  strings.ToLower(u.Preferences.WeekStart.String()),
+			UnitSystem: u.Preferences.UnitSystem,
+			Notifications: NotificationPreferencesDto{
+				Email: u.Preferences.EmailNotifications,
+				Push:  u.Preferences.PushNotifications,
+			},
+		},
+	}
+}
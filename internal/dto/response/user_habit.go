@@ -1,6 +1,9 @@
 package response
 
-import "routinist/internal/domain/model"
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
 
 type UserHabitDto struct {
 	ID            uint                `json:"id"`
@@ -8,16 +11,22 @@ type UserHabitDto struct {
 	Icon          string              `json:"icon"`
 	Goal          float64             `json:"goal"`
 	GoalFrequency model.GoalFrequency `json:"goal_frequency"`
+	Schedule      ScheduleDto         `json:"schedule"`
 	Unit          UnitDto             `json:"unit"`
+	Streak        StreakDto           `json:"streak"`
+	ArchivedAt    *time.Time          `json:"archived_at,omitempty"`
 }
 
-func ToUserHabitDto(uh *model.UserHabit) UserHabitDto {
+func ToUserHabitDto(uh *model.UserHabit, streak StreakDto) UserHabitDto {
 	return UserHabitDto{
 		ID:            uh.ID,
 		Name:          uh.Habit.Name,
 		Icon:          uh.Habit.Icon,
 		Goal:          uh.Goal,
 		GoalFrequency: uh.GoalFrequency,
+		Schedule:      toScheduleDto(uh.Schedule),
 		Unit:          toUnitDto(uh.Unit),
+		Streak:        streak,
+		ArchivedAt:    uh.ArchivedAt,
 	}
 }
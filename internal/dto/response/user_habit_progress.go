@@ -3,25 +3,35 @@ package response
 import "routinist/internal/domain/model"
 
 type UserHabitProgressDto struct {
-	ID            uint                `json:"id"`
-	Name          string              `json:"name"`
-	Icon          string              `json:"icon"`
-	Goal          float64             `json:"goal"`
-	GoalFrequency model.GoalFrequency `json:"goal_frequency"`
-	Unit          UnitDto             `json:"unit"`
-	CreatedAt     string              `json:"created_at"`
-	Progress      float64             `json:"progress"`
+	ID             uint                `json:"id"`
+	Name           string              `json:"name"`
+	Icon           string              `json:"icon"`
+	Goal           float64             `json:"goal"`
+	GoalFrequency  model.GoalFrequency `json:"goal_frequency"`
+	Schedule       ScheduleDto         `json:"schedule"`
+	Unit           UnitDto             `json:"unit"`
+	CreatedAt      string              `json:"created_at"`
+	Progress       float64             `json:"progress"`
+	PeriodProgress float64             `json:"period_progress"`
+	Period         string              `json:"period"`
+	IsCompleted    bool                `json:"is_completed"`
+	Streak         StreakDto           `json:"streak"`
 }
 
-func ToUserHabitProgressDto(uh *model.UserHabit, p *model.HabitProgress) UserHabitProgressDto {
+func ToUserHabitProgressDto(uh *model.UserHabit, p *model.HabitProgress, periodProgress float64, streak StreakDto) UserHabitProgressDto {
 	return UserHabitProgressDto{
-		ID:            uh.ID,
-		Name:          uh.Habit.Name,
-		Icon:          uh.Habit.Icon,
-		Goal:          uh.Goal,
-		GoalFrequency: uh.GoalFrequency,
-		Unit:          toUnitDto(uh.Unit),
-		CreatedAt:     p.Date.String(),
-		Progress:      p.Value,
+		ID:             uh.ID,
+		Name:           uh.Habit.Name,
+		Icon:           uh.Habit.Icon,
+		Goal:           uh.Goal,
+		GoalFrequency:  uh.GoalFrequency,
+		Schedule:       toScheduleDto(uh.Schedule),
+		Unit:           toUnitDto(uh.Unit),
+		CreatedAt:      p.Date.String(),
+		Progress:       p.Value,
+		PeriodProgress: periodProgress,
+		Period:         uh.GoalFrequency.PeriodLabel(),
+		IsCompleted:    periodProgress >= uh.Goal,
+		Streak:         streak,
 	}
 }
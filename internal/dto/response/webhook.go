@@ -0,0 +1,51 @@
+package response
+
+import (
+	"routinist/internal/domain/model"
+	"time"
+)
+
+type WebhookSubscriptionDto struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"` // only shown when created
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func ToWebhookSubscriptionDto(s *model.WebhookSubscription) WebhookSubscriptionDto {
+	return WebhookSubscriptionDto{
+		ID:        s.ID,
+		URL:       s.URL,
+		Events:    s.EventTypes(),
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+type WebhookDeliveryDto struct {
+	ID             uint                 `json:"id"`
+	EventID        string               `json:"event_id"`
+	EventType      string               `json:"event_type"`
+	Status         model.DeliveryStatus `json:"status"`
+	Attempts       int                  `json:"attempts"`
+	NextAttemptAt  *time.Time           `json:"next_attempt_at"`
+	LastStatusCode int                  `json:"last_status_code"`
+	LastError      string               `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time           `json:"delivered_at"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+func ToWebhookDeliveryDto(d *model.WebhookDelivery) WebhookDeliveryDto {
+	return WebhookDeliveryDto{
+		ID:             d.ID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		CreatedAt:      d.CreatedAt,
+	}
+}
@@ -0,0 +1,76 @@
+// Package events describes what happens to a user's habits, for anyone
+// outside the habit use case that wants to react to it.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+type Type string
+
+const (
+	HabitCreated        Type = "habit.created"
+	HabitUpdated        Type = "habit.updated"
+	ProgressLogged      Type = "progress.logged"
+	ProgressChanged     Type = "progress.changed"
+	ProgressExcused     Type = "progress.excused"
+	HabitCompleted      Type = "habit.completed"
+	MilestoneReached    Type = "milestone.reached"
+	AchievementUnlocked Type = "achievement.unlocked"
+)
+
+// Types lists every event type, in the order they are documented.
+var Types = []Type{
+	HabitCreated, HabitUpdated, ProgressLogged, ProgressChanged, ProgressExcused,
+	HabitCompleted, MilestoneReached, AchievementUnlocked,
+}
+
+func (t Type) IsValid() bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is something that happened to one user's data.
+type Event struct {
+	ID         string                 `json:"id"`
+	Type       Type                   `json:"type"`
+	UserID     uint                   `json:"user_id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// New returns an event with a random ID, occurring now.
+func New(t Type, userId uint, data map[string]interface{}) Event {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return Event{
+		ID:         hex.EncodeToString(b),
+		Type:       t,
+		UserID:     userId,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// Decode unmarshals the event's data into v, a pointer to a struct with json
+// tags matching the data keys.
+func (e Event) Decode(v interface{}) error {
+	b, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// Handler reacts to an event. Events are delivered at least once, so a
+// handler must be safe to call again with an event it already handled.
+type Handler func(e Event) error
@@ -0,0 +1,48 @@
+package mail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"routinist/pkg/logger"
+	"time"
+)
+
+// LogMailer writes emails to the log instead of sending them, for local
+// development.
+type LogMailer struct {
+	logger logger.Interface
+}
+
+func NewLogMailer(l logger.Interface) *LogMailer {
+	return &LogMailer{l}
+}
+
+func (m *LogMailer) Send(msg Message) error {
+	m.logger.Info("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer writes each email to its own .eml file in a directory, where
+// local tools and tests can pick them up.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir string, from string) *FileMailer {
+	return &FileMailer{dir, from}
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), filepath.Base(msg.To))
+	if err := os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail: %w", err)
+	}
+
+	return nil
+}
@@ -7,7 +7,16 @@ import (
 	"strings"
 )
 
-func JWTAuthMiddleware() gin.HandlerFunc {
+// SessionChecker tells whether the session an access token belongs to is
+// still valid.
+type SessionChecker interface {
+	CheckSession(claims *auth.Claims) (*auth.Session, error)
+}
+
+// JWTAuthMiddleware authenticates requests with a bearer access token. With
+// requireVerifiedEmail, users who have not verified their email are turned
+// away.
+func JWTAuthMiddleware(sessions SessionChecker, requireVerifiedEmail bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -27,9 +36,22 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		session, err := sessions.CheckSession(claims)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid or expired token"})
+			return
+		}
+
+		if requireVerifiedEmail && !session.EmailVerified {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Email address is not verified"})
+			return
+		}
+
 		// Attach user ID or email to context
 		c.Set("user_id", claims.ID)
 		c.Set("email", claims.Email)
+		c.Set("session_id", claims.SessionID)
+		c.Set("email_verified", session.EmailVerified)
 		c.Set("expired_at", claims.ExpiresAt.Time)
 
 		c.Next()
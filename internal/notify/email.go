@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"routinist/internal/mail"
+)
+
+// EmailNotifier sends notifications by email to the users who want them.
+type EmailNotifier struct {
+	mailer mail.Mailer
+}
+
+func NewEmailNotifier(mailer mail.Mailer) *EmailNotifier {
+	return &EmailNotifier{mailer}
+}
+
+func (n *EmailNotifier) Notify(msg Notification) error {
+	if !msg.EmailOptIn || msg.Email == "" {
+		return nil
+	}
+
+	return n.mailer.Send(mail.Message{
+		To:      msg.Email,
+		Subject: msg.Title,
+		Body:    msg.Body + "\n\nYou can turn off email notifications in your Routinist settings.\n",
+	})
+}
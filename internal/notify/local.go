@@ -0,0 +1,20 @@
+package notify
+
+import (
+	"routinist/pkg/logger"
+)
+
+// LogNotifier writes notifications to the log instead of delivering them, for
+// local development.
+type LogNotifier struct {
+	logger logger.Interface
+}
+
+func NewLogNotifier(l logger.Interface) *LogNotifier {
+	return &LogNotifier{l}
+}
+
+func (n *LogNotifier) Notify(msg Notification) error {
+	n.logger.Info("notification to user %d: %s\n%s", msg.UserID, msg.Title, msg.Body)
+	return nil
+}
@@ -0,0 +1,18 @@
+package notify
+
+import "errors"
+
+// MultiNotifier delivers each notification through all of its notifiers,
+// such as push and email.
+type MultiNotifier []Notifier
+
+func (m MultiNotifier) Notify(n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
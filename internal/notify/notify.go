@@ -0,0 +1,21 @@
+// Package notify delivers notifications, such as habit reminders, to users.
+package notify
+
+// Notification is a short message for one user.
+type Notification struct {
+	UserID uint              `json:"user_id"`
+	Email  string            `json:"email"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"` // e.g. the user habit it is about
+
+	// EmailOptIn and PushOptIn are whether the user wants notifications by
+	// email and by push.
+	EmailOptIn bool `json:"-"`
+	PushOptIn  bool `json:"-"`
+}
+
+// Notifier delivers notifications.
+type Notifier interface {
+	Notify(n Notification) error
+}
@@ -0,0 +1,135 @@
+package notify
+
+import (
+	"errors"
+	"fmt"
+	"routinist/internal/domain/model"
+	"routinist/pkg/logger"
+	"sync"
+)
+
+// ErrInvalidToken is returned by providers when the push service no longer
+// knows a device token, for instance because the app was uninstalled.
+var ErrInvalidToken = errors.New("invalid device token")
+
+// PushProvider sends a notification to one device through a push service
+// such as APNs or FCM.
+type PushProvider interface {
+	Push(device model.Device, n Notification) error
+}
+
+// DeviceStore is where PushNotifier finds devices and records deliveries.
+type DeviceStore interface {
+	GetDevices(userId uint) ([]model.Device, error)
+	DeleteDevice(deviceId uint) error
+	RecordDelivery(delivery *model.PushDelivery) error
+}
+
+// PushNotifier fans notifications out to every device of the user, pruning
+// devices whose token the provider rejects.
+type PushNotifier struct {
+	devices  DeviceStore
+	provider PushProvider
+	logger   logger.Interface
+}
+
+func NewPushNotifier(devices DeviceStore, provider PushProvider, l logger.Interface) *PushNotifier {
+	return &PushNotifier{devices, provider, l}
+}
+
+// Notify fails only when no device could be reached.
+func (p *PushNotifier) Notify(n Notification) error {
+	if !n.PushOptIn {
+		return nil
+	}
+
+	devices, err := p.devices.GetDevices(n.UserID)
+	if err != nil {
+		return err
+	}
+
+	var failures []error
+	for _, device := range devices {
+		delivery := &model.PushDelivery{
+			UserID:   n.UserID,
+			DeviceID: device.ID,
+			Title:    n.Title,
+			Status:   model.DeliverySent,
+		}
+
+		err := p.provider.Push(device, n)
+		switch {
+		case errors.Is(err, ErrInvalidToken):
+			delivery.Status = model.DeliveryInvalidToken
+			delivery.Error = err.Error()
+			if err := p.devices.DeleteDevice(device.ID); err != nil {
+				p.logger.Error(err)
+			}
+		case err != nil:
+			delivery.Status = model.DeliveryFailed
+			delivery.Error = err.Error()
+			failures = append(failures, fmt.Errorf("device %d: %w", device.ID, err))
+		}
+
+		if err := p.devices.RecordDelivery(delivery); err != nil {
+			p.logger.Error(err)
+		}
+	}
+
+	if len(failures) > 0 && len(failures) == len(devices) {
+		return errors.Join(failures...)
+	}
+	return nil
+}
+
+// FakePushProvider keeps pushes in memory instead of sending them, for local
+// development and tests. Tokens marked invalid are rejected like a real push
+// service would.
+type FakePushProvider struct {
+	mu      sync.Mutex
+	pushes  []FakePush
+	invalid map[string]bool
+	logger  logger.Interface
+}
+
+type FakePush struct {
+	Device       model.Device
+	Notification Notification
+}
+
+// NewFakePushProvider returns a fake provider, logging each push when l is
+// not nil.
+func NewFakePushProvider(l logger.Interface) *FakePushProvider {
+	return &FakePushProvider{invalid: make(map[string]bool), logger: l}
+}
+
+func (f *FakePushProvider) Push(device model.Device, n Notification) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.invalid[device.Token] {
+		return ErrInvalidToken
+	}
+
+	f.pushes = append(f.pushes, FakePush{device, n})
+	if f.logger != nil {
+		f.logger.Info("push to %s device %d: %s", device.Platform, device.ID, n.Title)
+	}
+	return nil
+}
+
+// MarkInvalid makes later pushes to the token fail with ErrInvalidToken.
+func (f *FakePushProvider) MarkInvalid(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.invalid[token] = true
+}
+
+// Pushes returns the pushes sent so far.
+func (f *FakePushProvider) Pushes() []FakePush {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]FakePush(nil), f.pushes...)
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+const pruneInterval = time.Minute
+
+type memoryEntry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// MemoryStore keeps failures in process. It is lost on restart and not shared
+// between instances; use PostgresStore when running more than one.
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastPrune time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Get(key string) (State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return State{}, nil
+	}
+
+	return State{Failures: entry.failures, BlockedUntil: entry.blockedUntil}, nil
+}
+
+func (s *MemoryStore) AddFailure(key string, now time.Time, since time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now, since)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+
+	if entry.lastFailure.Before(since) {
+		entry.failures = 0
+	}
+
+	entry.failures++
+	entry.lastFailure = now
+	return entry.failures, nil
+}
+
+func (s *MemoryStore) Block(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && until.After(entry.blockedUntil) {
+		entry.blockedUntil = until
+	}
+	return nil
+}
+
+func (s *MemoryStore) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// prune drops the keys whose failures are forgotten and that are no longer
+// blocked, so that the map does not grow without bound.
+func (s *MemoryStore) prune(now time.Time, since time.Time) {
+	if now.Sub(s.lastPrune) < pruneInterval {
+		return
+	}
+	s.lastPrune = now
+
+	for key, entry := range s.entries {
+		if entry.lastFailure.Before(since) && entry.blockedUntil.Before(now) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"routinist/internal/domain/model"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PostgresStore keeps failures in the rate_limit_buckets table, shared by
+// every instance of the API.
+type PostgresStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Get(key string) (State, error) {
+	var bucket model.RateLimitBucket
+	result := s.db.Where("key = ?", key).Limit(1).Find(&bucket)
+	if result.Error != nil {
+		return State{}, result.Error
+	}
+
+	state := State{Failures: bucket.Failures}
+	if bucket.BlockedUntil != nil {
+		state.BlockedUntil = *bucket.BlockedUntil
+	}
+	return state, nil
+}
+
+// AddFailure increments the count in a single upsert, so that concurrent
+// attempts cannot overwrite each other's failures.
+func (s *PostgresStore) AddFailure(key string, now time.Time, since time.Time) (int, error) {
+	s.prune(now, since)
+
+	var failures int
+	err := s.db.Raw(`
+		INSERT INTO rate_limit_buckets (key, failures, last_failure_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN rate_limit_buckets.last_failure_at < ? THEN 1
+				ELSE rate_limit_buckets.failures + 1 END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING failures`,
+		key, now, since,
+	).Scan(&failures).Error
+
+	return failures, err
+}
+
+func (s *PostgresStore) Block(key string, until time.Time) error {
+	return s.db.Model(&model.RateLimitBucket{}).
+		Where("key = ? AND (blocked_until IS NULL OR blocked_until < ?)", key, until).
+		Update("blocked_until", until).Error
+}
+
+func (s *PostgresStore) Reset(key string) error {
+	return s.db.Where("key = ?", key).Delete(&model.RateLimitBucket{}).Error
+}
+
+// prune deletes forgotten buckets at most once a minute. A failure doing so
+// is not the caller's concern; the rows are deleted on a later try.
+func (s *PostgresStore) prune(now time.Time, since time.Time) {
+	s.mu.Lock()
+	if now.Sub(s.lastPrune) < pruneInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastPrune = now
+	s.mu.Unlock()
+
+	s.db.Where("last_failure_at < ? AND (blocked_until IS NULL OR blocked_until < ?)", since, now).
+		Delete(&model.RateLimitBucket{})
+}
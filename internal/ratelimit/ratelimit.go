@@ -0,0 +1,130 @@
+// Package ratelimit slows down repeated failures, such as wrong passwords,
+// with an exponentially growing delay and a temporary lockout.
+package ratelimit
+
+import (
+	"time"
+)
+
+// State is what a store knows about a key.
+type State struct {
+	Failures     int
+	BlockedUntil time.Time
+}
+
+// Store keeps failure counts per key, such as an account or an IP address.
+type Store interface {
+	// Get returns the key's state, the zero State when it has none.
+	Get(key string) (State, error)
+	// AddFailure counts a failure at now, first forgetting the key's
+	// failures when the last one was before since, and returns the count.
+	AddFailure(key string, now time.Time, since time.Time) (int, error)
+	// Block refuses the key until the given time.
+	Block(key string, until time.Time) error
+	// Reset forgets the key.
+	Reset(key string) error
+}
+
+// Policy says how a limiter reacts to failures.
+type Policy struct {
+	FreeAttempts    int           // failures allowed before any delay
+	BaseDelay       time.Duration // delay after the first failure past FreeAttempts, doubling with each one
+	MaxDelay        time.Duration
+	LockoutAfter    int // failures that lock the key out for LockoutDuration, 0 for never
+	LockoutDuration time.Duration
+	Window          time.Duration // a key's failures are forgotten after this long without one
+}
+
+// Status is the state of the most restricted key after a failure.
+type Status struct {
+	Failures   int
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	store  Store
+	policy Policy
+}
+
+func New(store Store, policy Policy) *Limiter {
+	return &Limiter{store, policy}
+}
+
+// Allow returns how long to wait before trying again, zero when none of the
+// keys are blocked.
+func (l *Limiter) Allow(keys ...string) (time.Duration, error) {
+	now := time.Now()
+
+	var wait time.Duration
+	for _, key := range keys {
+		state, err := l.store.Get(key)
+		if err != nil {
+			return 0, err
+		}
+
+		if d := state.BlockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+
+	return wait, nil
+}
+
+// Fail records a failure for each key and blocks those past the free
+// attempts.
+func (l *Limiter) Fail(keys ...string) (Status, error) {
+	now := time.Now()
+
+	var status Status
+	for _, key := range keys {
+		failures, err := l.store.AddFailure(key, now, now.Add(-l.policy.Window))
+		if err != nil {
+			return status, err
+		}
+
+		delay := l.delay(failures)
+		if delay > 0 {
+			if err := l.store.Block(key, now.Add(delay)); err != nil {
+				return status, err
+			}
+		}
+
+		if delay > status.RetryAfter || (delay == status.RetryAfter && failures > status.Failures) {
+			status = Status{Failures: failures, RetryAfter: delay}
+		}
+	}
+
+	return status, nil
+}
+
+// Reset forgets the keys' failures, for instance after a successful login.
+func (l *Limiter) Reset(keys ...string) error {
+	for _, key := range keys {
+		if err := l.store.Reset(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *Limiter) delay(failures int) time.Duration {
+	if l.policy.LockoutAfter > 0 && failures >= l.policy.LockoutAfter {
+		return l.policy.LockoutDuration
+	}
+
+	over := failures - l.policy.FreeAttempts
+	if over <= 0 {
+		return 0
+	}
+
+	delay := l.policy.BaseDelay
+	for i := 1; i < over && delay < l.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > l.policy.MaxDelay {
+		delay = l.policy.MaxDelay
+	}
+	return delay
+}
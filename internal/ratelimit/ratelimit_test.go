@@ -0,0 +1,198 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeAttempts:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        8 * time.Second,
+	LockoutAfter:    10,
+	LockoutDuration: time.Hour,
+	Window:          time.Hour,
+}
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{7, 8 * time.Second},
+		{9, 8 * time.Second},
+		{10, time.Hour},
+		{50, time.Hour},
+	}
+
+	l := New(NewMemoryStore(), testPolicy)
+	for _, tt := range tests {
+		if got := l.delay(tt.failures); got != tt.want {
+			t.Errorf("delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestDelayWithoutLockout(t *testing.T) {
+	policy := testPolicy
+	policy.LockoutAfter = 0
+
+	l := New(NewMemoryStore(), policy)
+	if got := l.delay(1000); got != policy.MaxDelay {
+		t.Fatalf("delay(1000) = %v, want %v", got, policy.MaxDelay)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      []string // one failure per key, in order
+		reset     []string
+		allow     []string
+		wantWait  time.Duration // at least this long, 0 for not blocked
+		wantFails int           // failures of the most restricted key
+	}{
+		{
+			name:  "free attempts",
+			fail:  []string{"a", "a", "a"},
+			allow: []string{"a"},
+		},
+		{
+			name:      "backoff past the free attempts",
+			fail:      []string{"a", "a", "a", "a", "a"},
+			allow:     []string{"a"},
+			wantWait:  time.Second,
+			wantFails: 5,
+		},
+		{
+			name:      "lockout",
+			fail:      []string{"a", "a", "a", "a", "a", "a", "a", "a", "a", "a"},
+			allow:     []string{"a"},
+			wantWait:  time.Hour - time.Minute,
+			wantFails: 10,
+		},
+		{
+			name:  "keys are counted apart",
+			fail:  []string{"a", "b", "a", "b", "a", "b"},
+			allow: []string{"a", "b"},
+		},
+		{
+			name:      "the most restricted key wins",
+			fail:      []string{"a", "a", "a", "a"},
+			allow:     []string{"b", "a"},
+			wantWait:  time.Second - 100*time.Millisecond,
+			wantFails: 4,
+		},
+		{
+			name:  "reset",
+			fail:  []string{"a", "a", "a", "a", "a"},
+			reset: []string{"a"},
+			allow: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := New(NewMemoryStore(), testPolicy)
+
+			var status Status
+			for _, key := range tt.fail {
+				var err error
+				if status, err = l.Fail(key); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.wantFails > 0 && status.Failures != tt.wantFails {
+				t.Fatalf("got %d failures, want %d", status.Failures, tt.wantFails)
+			}
+
+			if err := l.Reset(tt.reset...); err != nil {
+				t.Fatal(err)
+			}
+
+			wait, err := l.Allow(tt.allow...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantWait == 0 && wait != 0 {
+				t.Fatalf("blocked for %v, want not blocked", wait)
+			}
+			if wait < tt.wantWait {
+				t.Fatalf("blocked for %v, want at least %v", wait, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestFailReportsTheMostRestrictedKey(t *testing.T) {
+	l := New(NewMemoryStore(), testPolicy)
+
+	for i := 0; i < 5; i++ {
+		if _, err := l.Fail("account"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := l.Fail("ip", "account")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Status{Failures: 6, RetryAfter: 4 * time.Second}
+	if status != want {
+		t.Fatalf("got %+v, want %+v", status, want)
+	}
+}
+
+func TestMemoryStoreForgetsFailuresAfterTheWindow(t *testing.T) {
+	s := NewMemoryStore()
+	start := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if _, err := s.AddFailure("a", start, start.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	later := start.Add(2 * time.Hour)
+	failures, err := s.AddFailure("a", later, later.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures != 1 {
+		t.Fatalf("got %d failures, want 1", failures)
+	}
+}
+
+func TestMemoryStorePrunesForgottenKeys(t *testing.T) {
+	s := NewMemoryStore()
+	start := time.Now()
+
+	if _, err := s.AddFailure("stale", start, start.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.AddFailure("blocked", start, start.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Block("blocked", start.Add(3*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	later := start.Add(2 * time.Hour)
+	if _, err := s.AddFailure("fresh", later, later.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.entries["stale"]; ok {
+		t.Error("a forgotten key was kept")
+	}
+	if _, ok := s.entries["blocked"]; !ok {
+		t.Error("a blocked key was pruned")
+	}
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"routinist/internal/domain/model"
+	"routinist/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AchievementRepo struct {
+	db     *gorm.DB
+	logger *logger.Logger
+}
+
+func NewAchievementRepo(db *gorm.DB, logger *logger.Logger) *AchievementRepo {
+	return &AchievementRepo{db, logger}
+}
+
+func (r *AchievementRepo) GetAchievements() ([]model.Achievement, error) {
+	var achievements []model.Achievement
+	if err := r.db.Order("id").Find(&achievements).Error; err != nil {
+		r.logger.Error("failed to get achievements", err)
+		return nil, err
+	}
+
+	return achievements, nil
+}
+
+func (r *AchievementRepo) GetUserAchievements(userId uint) ([]model.UserAchievement, error) {
+	var unlocked []model.UserAchievement
+	err := r.db.Where("user_id = ?", userId).
+		Order("unlocked_at").
+		Find(&unlocked).Error
+
+	if err != nil {
+		r.logger.Error("failed to get user achievements", err)
+		return nil, err
+	}
+
+	return unlocked, nil
+}
+
+// UnlockAchievement records that the user unlocked an achievement at the given
+// time. It returns false when it was already unlocked, keeping the first time.
+func (r *AchievementRepo) UnlockAchievement(db *gorm.DB, userId uint, achievementId uint, at time.Time) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserAchievement{
+		UserID:        userId,
+		AchievementID: achievementId,
+		UnlockedAt:    at,
+	})
+
+	if result.Error != nil {
+		r.logger.Error("failed to unlock achievement", result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// userStreaks selects the streaks of the user's habits, of the given goal
+// frequency when set.
+func (r *AchievementRepo) userStreaks(userId uint, frequency model.GoalFrequency) *gorm.DB {
+	query := r.db.Model(&model.Streak{}).
+		Joins("JOIN user_habits ON user_habits.id = streaks.user_habit_id").
+		Where("user_habits.user_id = ?", userId)
+
+	if frequency != "" {
+		query = query.Where("user_habits.goal_frequency = ?", frequency)
+	}
+	return query
+}
+
+// GetCompletedPeriods counts the goal periods the user completed. Every
+// completed period belongs to exactly one streak, so this adds up their
+// lengths.
+func (r *AchievementRepo) GetCompletedPeriods(userId uint, frequency model.GoalFrequency) (uint, error) {
+	var total uint
+	err := r.userStreaks(userId, frequency).
+		Select("COALESCE(SUM(streaks.length), 0)").
+		Scan(&total).Error
+
+	if err != nil {
+		r.logger.Error("failed to count completed periods", err)
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetLongestStreak returns the length of the user's longest streak ever.
+func (r *AchievementRepo) GetLongestStreak(userId uint, frequency model.GoalFrequency) (uint, error) {
+	var longest uint
+	err := r.userStreaks(userId, frequency).
+		Select("COALESCE(MAX(streaks.length), 0)").
+		Scan(&longest).Error
+
+	if err != nil {
+		r.logger.Error("failed to get longest streak", err)
+		return 0, err
+	}
+
+	return longest, nil
+}
+
+func (r *AchievementRepo) GetDB() *gorm.DB {
+	return r.db
+}
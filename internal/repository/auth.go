@@ -1,8 +1,8 @@
 package repository
 
 import (
+	goerrors "errors"
 	"math/rand"
-	"routinist/internal/auth"
 	"routinist/internal/domain/errors"
 	"routinist/internal/domain/model"
 	"routinist/internal/dto/request"
@@ -24,20 +24,20 @@ func NewAuthRepo(db *gorm.DB, logger *logger.Logger) *AuthRepo {
 	}
 }
 
-func (rp *AuthRepo) Register(db *gorm.DB, e *request.RegisterRequestDTO) (*request.AuthResponseDTO, uint, error) {
+func (rp *AuthRepo) Register(db *gorm.DB, e *request.RegisterRequestDTO) (*model.User, error) {
 	// Check if email already exists
 	var user model.User
 
 	result := db.Where("email = ?", e.Email).Limit(1).Find(&user)
 	exists := result.RowsAffected > 0
 	if exists {
-		return &request.AuthResponseDTO{}, 0, errors.ErrEmailAlreadyExists
+		return nil, errors.ErrEmailAlreadyExists
 	}
 
 	// Hash the password
 	hash, err := bcrypt.GenerateFromPassword([]byte(e.Password), bcrypt.DefaultCost)
 	if err != nil {
-		return &request.AuthResponseDTO{}, 0, errors.ErrFailedToHashPassword
+		return nil, errors.ErrFailedToHashPassword
 	}
 
 	// Set default name if not provided
@@ -46,47 +46,435 @@ func (rp *AuthRepo) Register(db *gorm.DB, e *request.RegisterRequestDTO) (*reque
 		name = generateRandomName()
 	}
 
+	gender := model.Gender(e.Gender)
+	if gender == "" {
+		gender = model.GenderUnspecified
+	}
+
+	timeZone := e.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
 	// Create user in database
 	user = model.User{
 		Email:    e.Email,
 		Password: string(hash),
 		Name:     name,
-		Gender:   e.Gender,
+		Gender:   gender,
+		TimeZone: timeZone,
 	}
 
 	result = db.Create(&user)
 	if result.Error != nil {
-		return &request.AuthResponseDTO{}, 0, result.Error
+		return nil, result.Error
 	}
 
 	rp.logger.Info("User created: ", user)
 
-	token, err := auth.GenerateJWT(user.Email, user.ID)
+	return &user, nil
+}
+
+func (rp *AuthRepo) Login(e *request.LoginRequestDTO) (*model.User, error) {
+	var user model.User
+	result := rp.db.Where("email = ?", e.Email).Limit(1).Find(&user)
+
+	if result.Error != nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(e.Password)); err != nil {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	return &user, nil
+}
+
+func (rp *AuthRepo) CreateRefreshToken(db *gorm.DB, token *model.RefreshToken) error {
+	if err := db.Create(token).Error; err != nil {
+		rp.logger.Error("failed to create refresh token", err)
+		return err
+	}
+
+	return nil
+}
+
+func (rp *AuthRepo) GetRefreshToken(tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := rp.db.Where("token_hash = ?", tokenHash).First(&token).Error
+
+	if err != nil {
+		if goerrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrInvalidRefreshToken
+		}
+		rp.logger.Error("failed to get refresh token", err)
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RotateRefreshToken revokes old in favour of next. It fails with
+// ErrRefreshTokenReused when old was revoked in the meantime, so a token can
+// only ever be rotated once.
+func (rp *AuthRepo) RotateRefreshToken(db *gorm.DB, old *model.RefreshToken, next *model.RefreshToken) error {
+	if err := db.Create(next).Error; err != nil {
+		rp.logger.Error("failed to create refresh token", err)
+		return err
+	}
+
+	result := db.Model(&model.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", old.ID).
+		Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"replaced_by_id": next.ID,
+		})
+
+	if result.Error != nil {
+		rp.logger.Error("failed to revoke refresh token", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes every token descended from the same login.
+func (rp *AuthRepo) RevokeRefreshTokenFamily(familyId string) error {
+	err := rp.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", time.Now()).Error
+
 	if err != nil {
-		return &request.AuthResponseDTO{}, 0, errors.ErrFailedToGenerateJWT
+		rp.logger.Error("failed to revoke refresh token family", err)
+		return err
 	}
 
-	return &request.AuthResponseDTO{Token: token}, user.ID, nil
+	return nil
 }
 
-func (rp *AuthRepo) Login(e *request.LoginRequestDTO) (*request.AuthResponseDTO, uint, error) {
+// RevokeUserRefreshTokens revokes every session of a user but keepSessionId,
+// which may be empty.
+func (rp *AuthRepo) RevokeUserRefreshTokens(db *gorm.DB, userId uint, keepSessionId string) error {
+	err := db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND revoked_at IS NULL", userId, keepSessionId).
+		Update("revoked_at", time.Now()).Error
+
+	if err != nil {
+		rp.logger.Error("failed to revoke refresh tokens", err)
+		return err
+	}
+
+	return nil
+}
+
+// IsSessionActive reports whether a session still holds a usable refresh
+// token, which is the case until it is logged out, revoked or expires.
+func (rp *AuthRepo) IsSessionActive(sessionId string) (bool, error) {
+	var count int64
+	err := rp.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionId, time.Now()).
+		Count(&count).Error
+
+	if err != nil {
+		rp.logger.Error("failed to check session", err)
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// GetUserByEmail returns nil without an error when no user has the email.
+func (rp *AuthRepo) GetUserByEmail(email string) (*model.User, error) {
 	var user model.User
-	result := rp.db.Where("email = ?", e.Email).Limit(1).Find(&user)
+	result := rp.db.Where("email = ?", email).Limit(1).Find(&user)
 
 	if result.Error != nil {
-		return &request.AuthResponseDTO{}, 0, errors.ErrInvalidCredentials
+		rp.logger.Error("failed to get user", result.Error)
+		return nil, result.Error
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(e.Password)); err != nil {
-		return &request.AuthResponseDTO{}, 0, errors.ErrInvalidCredentials
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &user, nil
+}
+
+// CheckPassword fails with ErrInvalidCredentials unless password is the
+// user's current password.
+func (rp *AuthRepo) CheckPassword(userId uint, password string) error {
+	var user model.User
+	if err := rp.db.Where("id = ?", userId).First(&user).Error; err != nil {
+		rp.logger.Error("failed to get user", err)
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return errors.ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// UpdatePassword hashes and stores a new password for the user.
+func (rp *AuthRepo) UpdatePassword(db *gorm.DB, userId uint, password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.ErrFailedToHashPassword
+	}
+
+	err = db.Model(&model.User{}).
+		Where("id = ?", userId).
+		Update("password", string(hash)).Error
+
+	if err != nil {
+		rp.logger.Error("failed to update password", err)
+		return err
+	}
+
+	return nil
+}
+
+func (rp *AuthRepo) CreatePasswordResetToken(token *model.PasswordResetToken) error {
+	if err := rp.db.Create(token).Error; err != nil {
+		rp.logger.Error("failed to create password reset token", err)
+		return err
+	}
+
+	return nil
+}
+
+func (rp *AuthRepo) GetPasswordResetToken(tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := rp.db.Where("token_hash = ?", tokenHash).First(&token).Error
+
+	if err != nil {
+		if goerrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrInvalidResetToken
+		}
+		rp.logger.Error("failed to get password reset token", err)
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// UsePasswordResetToken marks a reset token as used, together with any other
+// token still outstanding for the same user. It fails with
+// ErrInvalidResetToken when the token was used in the meantime.
+func (rp *AuthRepo) UsePasswordResetToken(db *gorm.DB, token *model.PasswordResetToken) error {
+	now := time.Now()
+	result := db.Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+
+	if result.Error != nil {
+		rp.logger.Error("failed to use password reset token", result.Error)
+		return result.Error
 	}
 
-	token, err := auth.GenerateJWT(user.Email, user.ID)
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidResetToken
+	}
+
+	err := db.Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", token.UserID).
+		Update("used_at", now).Error
+
 	if err != nil {
-		return &request.AuthResponseDTO{}, 0, errors.ErrFailedToGenerateJWT
+		rp.logger.Error("failed to invalidate password reset tokens", err)
+		return err
+	}
+
+	return nil
+}
+
+func (rp *AuthRepo) CreateEmailVerificationToken(token *model.EmailVerificationToken) error {
+	if err := rp.db.Create(token).Error; err != nil {
+		rp.logger.Error("failed to create email verification token", err)
+		return err
+	}
+
+	return nil
+}
+
+func (rp *AuthRepo) GetEmailVerificationToken(tokenHash string) (*model.EmailVerificationToken, error) {
+	var token model.EmailVerificationToken
+	err := rp.db.Where("token_hash = ?", tokenHash).First(&token).Error
+
+	if err != nil {
+		if goerrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrInvalidVerifyToken
+		}
+		rp.logger.Error("failed to get email verification token", err)
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// CountEmailVerificationTokens counts the verification emails sent to a user
+// since the given time.
+func (rp *AuthRepo) CountEmailVerificationTokens(userId uint, since time.Time) (int64, error) {
+	var count int64
+	err := rp.db.Model(&model.EmailVerificationToken{}).
+		Where("user_id = ? AND created_at >= ?", userId, since).
+		Count(&count).Error
+
+	if err != nil {
+		rp.logger.Error("failed to count email verification tokens", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// UseEmailVerificationToken marks a verification token as used, together with
+// any other token still outstanding for the same user. It fails with
+// ErrInvalidVerifyToken when the token was used in the meantime.
+func (rp *AuthRepo) UseEmailVerificationToken(db *gorm.DB, token *model.EmailVerificationToken) error {
+	now := time.Now()
+	result := db.Model(&model.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+
+	if result.Error != nil {
+		rp.logger.Error("failed to use email verification token", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidVerifyToken
+	}
+
+	err := db.Model(&model.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", token.UserID).
+		Update("used_at", now).Error
+
+	if err != nil {
+		rp.logger.Error("failed to invalidate email verification tokens", err)
+		return err
+	}
+
+	return nil
+}
+
+// MarkEmailVerified sets the user's email to the verified address.
+func (rp *AuthRepo) MarkEmailVerified(db *gorm.DB, userId uint, email string) error {
+	var count int64
+	err := db.Model(&model.User{}).
+		Where("email = ? AND id <> ?", email, userId).
+		Count(&count).Error
+
+	if err != nil {
+		rp.logger.Error("failed to check email", err)
+		return err
+	}
+
+	if count > 0 {
+		return errors.ErrEmailAlreadyExists
+	}
+
+	err = db.Model(&model.User{}).
+		Where("id = ?", userId).
+		Updates(map[string]interface{}{
+			"email":       email,
+			"verified_at": time.Now(),
+		}).Error
+
+	if err != nil {
+		rp.logger.Error("failed to mark email verified", err)
+		return err
+	}
+
+	return nil
+}
+
+// RegisterExternal creates a user signing up through an identity provider.
+// The provider has verified the email, and the user has no password until
+// they set one through a password reset.
+func (rp *AuthRepo) RegisterExternal(db *gorm.DB, email string, name string, timeZone string) (*model.User, error) {
+	var count int64
+	if err := db.Model(&model.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		rp.logger.Error("failed to check email", err)
+		return nil, err
+	}
+
+	if count > 0 {
+		return nil, errors.ErrEmailAlreadyExists
+	}
+
+	if name == "" {
+		name = generateRandomName()
+	}
+
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+
+	now := time.Now()
+	user := model.User{
+		Email:      email,
+		Name:       name,
+		Gender:     model.GenderUnspecified,
+		TimeZone:   timeZone,
+		VerifiedAt: &now,
+	}
+
+	if err := db.Create(&user).Error; err != nil {
+		rp.logger.Error("failed to create user", err)
+		return nil, err
+	}
+
+	rp.logger.Info("User created: %d", user.ID)
+
+	return &user, nil
+}
+
+// ClearPassword removes the user's password, so that only their linked
+// identities or a password reset can sign them in.
+func (rp *AuthRepo) ClearPassword(db *gorm.DB, userId uint) error {
+	err := db.Model(&model.User{}).
+		Where("id = ?", userId).
+		Update("password", "").Error
+
+	if err != nil {
+		rp.logger.Error("failed to clear password", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetExternalIdentity returns nil without an error when the provider's
+// subject is not linked to any user.
+func (rp *AuthRepo) GetExternalIdentity(provider string, subject string) (*model.ExternalIdentity, error) {
+	var identity model.ExternalIdentity
+	result := rp.db.Where("provider = ? AND subject = ?", provider, subject).Limit(1).Find(&identity)
+
+	if result.Error != nil {
+		rp.logger.Error("failed to get external identity", result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &identity, nil
+}
+
+func (rp *AuthRepo) CreateExternalIdentity(db *gorm.DB, identity *model.ExternalIdentity) error {
+	if err := db.Create(identity).Error; err != nil {
+		rp.logger.Error("failed to create external identity", err)
+		return err
 	}
 
-	return &request.AuthResponseDTO{Token: token}, user.ID, nil
+	return nil
 }
 
 func (rp *AuthRepo) GetDB() *gorm.DB {
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"errors"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DeviceRepo struct {
+	db     *gorm.DB
+	logger *logger.Logger
+}
+
+func NewDeviceRepo(db *gorm.DB, logger *logger.Logger) *DeviceRepo {
+	return &DeviceRepo{db, logger}
+}
+
+// RegisterDevice saves the device, or refreshes it when its token is already
+// known. A token registered by another user moves to this one, as the device
+// changed hands or accounts.
+func (r *DeviceRepo) RegisterDevice(device *model.Device) error {
+	err := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "app_version", "last_seen_at", "updated_at"}),
+	}).Create(device).Error
+
+	if err != nil {
+		r.logger.Error("failed to register device", err)
+		return err
+	}
+
+	// The insert may have turned into an update of an existing row
+	return r.db.Where("token = ?", device.Token).First(device).Error
+}
+
+func (r *DeviceRepo) GetDevices(userId uint) ([]model.Device, error) {
+	var devices []model.Device
+	err := r.db.Where("user_id = ?", userId).
+		Order("last_seen_at DESC").
+		Find(&devices).Error
+
+	if err != nil {
+		r.logger.Error("failed to get devices", err)
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (r *DeviceRepo) GetDevice(userId uint, deviceId uint) (*model.Device, error) {
+	var device model.Device
+	err := r.db.Where("id = ? AND user_id = ?", deviceId, userId).First(&device).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErr.ErrDeviceNotFound
+		}
+		r.logger.Error("failed to get device", err)
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+func (r *DeviceRepo) DeleteDevice(deviceId uint) error {
+	if err := r.db.Delete(&model.Device{}, deviceId).Error; err != nil {
+		r.logger.Error("failed to delete device", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *DeviceRepo) RecordDelivery(delivery *model.PushDelivery) error {
+	if err := r.db.Create(delivery).Error; err != nil {
+		r.logger.Error("failed to record push delivery", err)
+		return err
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"errors"
 	"gorm.io/gorm/clause"
+	domainErr "routinist/internal/domain/errors"
 	"routinist/internal/domain/model"
 	"routinist/pkg/logger"
 	"time"
@@ -19,17 +20,26 @@ func NewHabitRepo(db *gorm.DB, logger *logger.Logger) *HabitRepo {
 	return &HabitRepo{db, logger}
 }
 
-func (r *HabitRepo) CreateUserHabit(db *gorm.DB, userId uint, habitId uint, unitId *uint, goal *float64) (*model.UserHabit, error) {
+func (r *HabitRepo) CreateUserHabit(db *gorm.DB, userId uint, habitId uint, unitId *uint, goal *float64, frequency model.GoalFrequency, schedule model.Schedule) (*model.UserHabit, error) {
 	var habit model.Habit
 	var unit model.Unit
 	var userHabit model.UserHabit
 
-	if err := db.Preload("Units").Where("id = ?", habitId).First(&habit).Error; err != nil {
+	// Catalog habits are shared, custom habits only belong to their owner
+	err := db.Preload("Units").
+		Where("id = ?", habitId).
+		Where("owner_id IS NULL OR owner_id = ?", userId).
+		First(&habit).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErr.ErrHabitNotFound
+		}
 		r.logger.Error("failed to get habit", err)
 		return nil, err
 	}
 
-	if unitId != nil {
+	if unitId != nil && *unitId != 0 {
 		for _, u := range habit.Units {
 			if *unitId == u.ID {
 				unit = u
@@ -37,19 +47,29 @@ func (r *HabitRepo) CreateUserHabit(db *gorm.DB, userId uint, habitId uint, unit
 			}
 
 		}
-	} else {
+	} else if len(habit.Units) > 0 {
 		unit = habit.Units[0]
 	}
 
+	if unit.ID == 0 {
+		return nil, domainErr.ErrInvalidUnit
+	}
+
 	if goal == nil {
 		goal = &habit.DefaultGoal
 	}
 
+	if frequency == "" {
+		frequency = model.FrequencyDaily
+	}
+
 	userHabit = model.UserHabit{
-		UserID:  userId,
-		HabitID: habitId,
-		UnitID:  unit.ID,
-		Goal:    *goal,
+		UserID:        userId,
+		HabitID:       habitId,
+		UnitID:        unit.ID,
+		Goal:          *goal,
+		GoalFrequency: frequency,
+		Schedule:      schedule,
 	}
 
 	result := db.Create(&userHabit)
@@ -64,6 +84,7 @@ func (r *HabitRepo) CreateUserHabit(db *gorm.DB, userId uint, habitId uint, unit
 func (r *HabitRepo) GetRandomHabits() (*[]model.Habit, error) {
 	var habits []model.Habit
 	if err := r.db.Preload("Units").
+		Where("owner_id IS NULL").
 		Order("RANDOM()").
 		Limit(8).
 		Find(&habits).Error; err != nil {
@@ -73,12 +94,99 @@ func (r *HabitRepo) GetRandomHabits() (*[]model.Habit, error) {
 	return &habits, nil
 }
 
-func (r *HabitRepo) GetTodayHabits(userId uint) ([]model.UserHabit, error) {
+// CreateCustomHabit adds a habit private to userId, measured in the given units.
+func (r *HabitRepo) CreateCustomHabit(db *gorm.DB, userId uint, name string, icon string, measurement model.Measurement, unitIds []uint, defaultGoal float64) (*model.Habit, error) {
+	var units []model.Unit
+	if err := db.Where("id IN ?", unitIds).Find(&units).Error; err != nil {
+		r.logger.Error("failed to get units", err)
+		return nil, err
+	}
+
+	if len(units) == 0 || len(units) != len(unitIds) {
+		return nil, domainErr.ErrInvalidUnit
+	}
+
+	for _, u := range units {
+		if u.Measurement != measurement {
+			return nil, domainErr.ErrInvalidUnit
+		}
+	}
+
+	habit := model.Habit{
+		Name:        name,
+		Icon:        icon,
+		Measurement: measurement,
+		DefaultGoal: defaultGoal,
+		OwnerID:     &userId,
+	}
+
+	if err := db.Omit("Units").Create(&habit).Error; err != nil {
+		r.logger.Error("failed to create custom habit", err)
+		return nil, err
+	}
+
+	for _, u := range units {
+		habitUnit := model.HabitUnit{
+			HabitID:     habit.ID,
+			UnitID:      u.ID,
+			DefaultGoal: defaultGoal,
+		}
+		if err := db.Create(&habitUnit).Error; err != nil {
+			r.logger.Error("failed to create habit unit", err)
+			return nil, err
+		}
+	}
+
+	habit.Units = units
+	return &habit, nil
+}
+
+func (r *HabitRepo) GetCustomHabits(userId uint) ([]model.Habit, error) {
+	var habits []model.Habit
+	err := r.db.Preload("Units").
+		Where("owner_id = ?", userId).
+		Order("created_at").
+		Find(&habits).Error
+
+	if err != nil {
+		r.logger.Error("failed to get custom habits", err)
+		return nil, err
+	}
+
+	return habits, nil
+}
+
+func (r *HabitRepo) GetUnits() ([]model.Unit, error) {
+	var units []model.Unit
+	if err := r.db.Order("id").Find(&units).Error; err != nil {
+		r.logger.Error("failed to get units", err)
+		return nil, err
+	}
+
+	return units, nil
+}
+
+func (r *HabitRepo) GetUnit(unitId uint) (*model.Unit, error) {
+	var unit model.Unit
+	if err := r.db.Where("id = ?", unitId).First(&unit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErr.ErrInvalidUnit
+		}
+		r.logger.Error("failed to get unit", err)
+		return nil, err
+	}
+
+	return &unit, nil
+}
+
+// GetTodayHabits returns the user's active habits that are due on today.
+func (r *HabitRepo) GetTodayHabits(userId uint, today time.Time) ([]model.UserHabit, error) {
 	var userHabits []model.UserHabit
 	err := r.db.Preload("Habit").
 		Preload("Unit").
+		Preload("User").
 		Where("user_id = ?", userId).
-		Where("goal_frequency = ?", model.FrequencyDaily).
+		Where("archived_at IS NULL").
 		Find(&userHabits).Error
 
 	if err != nil {
@@ -86,18 +194,30 @@ func (r *HabitRepo) GetTodayHabits(userId uint) ([]model.UserHabit, error) {
 		return nil, err
 	}
 
-	return userHabits, err
+	due := userHabits[:0]
+	for _, uh := range userHabits {
+		if uh.Schedule.IsDueOn(today) {
+			due = append(due, uh)
+		}
+	}
+
+	return due, err
 }
 
 func (r *HabitRepo) GetUserHabit(userId uint, userHabitId uint) (*model.UserHabit, error) {
 	var habit model.UserHabit
 
-	err := r.db.Preload("Habit").
+	err := r.db.Preload("Habit.Units").
+		Preload("Unit").
+		Preload("User").
 		Where("id = ?", userHabitId).
 		Where("user_id = ?", userId).
 		First(&habit).Error
 
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErr.ErrHabitNotFound
+		}
 		r.logger.Error("failed to get habit", err)
 		return nil, err
 	}
@@ -105,9 +225,13 @@ func (r *HabitRepo) GetUserHabit(userId uint, userHabitId uint) (*model.UserHabi
 	return &habit, nil
 }
 
-func (r *HabitRepo) CreateProgress(userHabitId uint, value float64) (*model.HabitProgress, error) {
+// CreateProgress logs a progress entry on day and returns the updated daily
+// progress with its entries. Pass the transaction the entry belongs to, or
+// the repository's DB.
+func (r *HabitRepo) CreateProgress(db *gorm.DB, userHabitId uint, day time.Time, value float64, note string, source model.EntrySource) (*model.HabitProgress, error) {
 	var uh model.UserHabit
-	err := r.db.Preload("Habit").
+	err := db.Preload("Habit").
+		Preload("User").
 		Where("id = ?", userHabitId).
 		First(&uh).Error
 
@@ -116,64 +240,163 @@ func (r *HabitRepo) CreateProgress(userHabitId uint, value float64) (*model.Habi
 		return nil, err
 	}
 
-	// If habit has progress, update it
-	p := model.HabitProgress{}
-	today := time.Now().Truncate(24 * time.Hour)
-	err = r.db.Where("user_habit_id = ? AND date = ?", userHabitId, today).First(&p).Error
+	ph := model.HabitProgress{
+		UserHabitID: uh.ID,
+		Date:        day,
+		RestDay:     !uh.Schedule.IsDueOn(day),
+	}
 
-	if err == nil {
-		r.logger.Info("habit progress already exists for today, updating it, id = ", p.ID, " value = ", value, "")
-		ph, err := r.UpdateProgress(p.ID, value)
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("user_habit_id = ? AND date = ?", uh.ID, day).
+			Omit("Entries").
+			FirstOrCreate(&ph).Error
 		if err != nil {
-			r.logger.Error("failed to update habit progress", err)
-			return nil, err
+			return err
+		}
+
+		if err := r.migrateLegacyValue(tx, &ph); err != nil {
+			return err
+		}
+
+		entry := model.ProgressEntry{
+			HabitProgressID: ph.ID,
+			UserHabitID:     uh.ID,
+			LoggedAt:        time.Now(),
+			Value:           value,
+			Note:            note,
+			Source:          source,
 		}
-		return ph, nil
+		if err := tx.Create(&entry).Error; err != nil {
+			return err
+		}
+
+		return r.recalculateProgress(tx, &uh, &ph)
+	})
+
+	if err != nil {
+		r.logger.Error("failed to create habit progress", err)
+		return nil, err
 	}
 
-	ph := model.HabitProgress{
-		UserHabitID: uh.ID,
-		Value:       value,
-		IsCompleted: value >= uh.Goal,
-		Date:        time.Now(),
+	return &ph, nil
+}
+
+// migrateLegacyValue turns a daily value logged before progress entries
+// existed into an entry, so that recalculating from entries keeps it.
+func (r *HabitRepo) migrateLegacyValue(db *gorm.DB, ph *model.HabitProgress) error {
+	if ph.Value == 0 {
+		return nil
 	}
 
-	result := r.db.Create(&ph)
+	var count int64
+	if err := db.Model(&model.ProgressEntry{}).Where("habit_progress_id = ?", ph.ID).Count(&count).Error; err != nil {
+		return err
+	}
 
-	if result.Error != nil {
-		return nil, result.Error
+	if count > 0 {
+		return nil
 	}
 
-	return &ph, nil
+	return db.Create(&model.ProgressEntry{
+		HabitProgressID: ph.ID,
+		UserHabitID:     ph.UserHabitID,
+		LoggedAt:        ph.Date,
+		Value:           ph.Value,
+		Source:          model.EntrySourceLegacy,
+	}).Error
 }
 
-func (r *HabitRepo) UpdateProgress(progressId uint, value float64) (*model.HabitProgress, error) {
-	var ph model.HabitProgress
-	err := r.db.Where("id = ?", progressId).First(&ph).Error
+// recalculateProgress sets the daily value to the sum of its entries and
+// re-evaluates completion, loading the entries onto ph.
+func (r *HabitRepo) recalculateProgress(db *gorm.DB, uh *model.UserHabit, ph *model.HabitProgress) error {
+	if err := db.Where("habit_progress_id = ?", ph.ID).Order("logged_at").Find(&ph.Entries).Error; err != nil {
+		return err
+	}
 
-	if err != nil {
-		r.logger.Error("failed to get habit progress", err)
-		return nil, err
+	var value float64
+	for _, e := range ph.Entries {
+		value += e.Value
 	}
+	ph.Value = value
+
+	if err := db.Model(ph).Update("value", ph.Value).Error; err != nil {
+		return err
+	}
+
+	return r.refreshCompletion(db, uh, ph)
+}
+
+// refreshCompletion marks progress as completed once the total logged over the
+// habit's goal period (day, week or month) up to that day reaches the goal.
+// Later days of the same period are re-evaluated too; earlier ones are left
+// untouched.
+func (r *HabitRepo) refreshCompletion(db *gorm.DB, uh *model.UserHabit, ph *model.HabitProgress) error {
+	from, to := uh.PeriodRange(ph.Date)
+
+	var rows []model.HabitProgress
+	err := db.Where("user_habit_id = ? AND date >= ? AND date < ?", uh.ID, from, to).
+		Order("date").
+		Find(&rows).Error
 
-	var uh model.UserHabit
-	err = r.db.Where("id = ?", ph.UserHabitID).First(&uh).Error
 	if err != nil {
-		r.logger.Error("failed to get user habit", err)
-		return nil, err
+		r.logger.Error("failed to get period progresses", err)
+		return err
 	}
 
-	ph.IsCompleted = ph.Value+value >= uh.Goal
-	ph.Value += value
+	var total float64
+	for i := range rows {
+		p := &rows[i]
+		total += p.Value
+		if p.Date.Before(ph.Date) {
+			continue
+		}
 
-	result := r.db.Save(&ph)
+		completed := total >= uh.Goal
+		status := p.Status
+		switch {
+		case completed:
+			status = model.ProgressStatusCompleted
+		case status == model.ProgressStatusCompleted || status == "":
+			status = model.ProgressStatusPending
+		}
 
-	if result.Error != nil {
-		r.logger.Error("failed to update habit progress", result.Error)
-		return nil, result.Error
+		if p.ID == ph.ID {
+			ph.IsCompleted = completed
+			ph.Status = status
+		}
+
+		if p.IsCompleted == completed && p.Status == status {
+			continue
+		}
+
+		err := db.Model(p).Updates(map[string]interface{}{
+			"is_completed": completed,
+			"status":       status,
+		}).Error
+
+		if err != nil {
+			r.logger.Error("failed to update habit progress completion", err)
+			return err
+		}
 	}
 
-	return &ph, nil
+	return nil
+}
+
+// GetPeriodProgress sums the progress logged for a user habit within [from, to).
+func (r *HabitRepo) GetPeriodProgress(db *gorm.DB, userHabitId uint, from, to time.Time) (float64, error) {
+	var total float64
+	err := db.Model(&model.HabitProgress{}).
+		Select("COALESCE(SUM(value), 0)").
+		Where("user_habit_id = ? AND date >= ? AND date < ?", userHabitId, from, to).
+		Scan(&total).Error
+
+	if err != nil {
+		r.logger.Error("failed to get period progress", err)
+		return 0, err
+	}
+
+	return total, nil
 }
 
 func (r *HabitRepo) GetProgress(userHabitId uint) (float64, error) {
@@ -191,36 +414,66 @@ func (r *HabitRepo) GetProgress(userHabitId uint) (float64, error) {
 	return ph.Value, nil
 }
 
-func (r *HabitRepo) GetProgressSummary(userId uint, from, to time.Time) (completed int64, total int64, err error) {
-	type SummaryResult struct {
-		Total     int64
-		Completed int64
-	}
-	var result SummaryResult
+// GetProgressByDate returns the progress of a user habit on day, or nil when
+// nothing was recorded.
+func (r *HabitRepo) GetProgressByDate(userHabitId uint, day time.Time) (*model.HabitProgress, error) {
+	return r.getProgressByDate(r.db, userHabitId, day)
+}
 
-	err = r.db.
-		Model(&model.HabitProgress{}).
-		Select(`
-			COUNT(*) AS total,
-			SUM(CASE WHEN is_completed THEN 1 ELSE 0 END) AS completed
-		`).
-		Joins("JOIN user_habits ON user_habits.id = habit_progresses.user_habit_id").
-		Where("user_habits.user_id = ? AND habit_progresses.date >= ? AND habit_progresses.date < ?", userId, from, to).
-		Scan(&result).Error
+func (r *HabitRepo) getProgressByDate(db *gorm.DB, userHabitId uint, day time.Time) (*model.HabitProgress, error) {
+	var ph model.HabitProgress
+	err := db.Where("user_habit_id = ? AND date = ?", userHabitId, day).First(&ph).Error
 
 	if err != nil {
-		r.logger.Error("failed to get habit progress summary", err)
-		return
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get habit progress", err)
+		return nil, err
 	}
 
-	return result.Completed, result.Total, nil
+	return &ph, nil
 }
 
-func (r *HabitRepo) EnsureTodayProgressForUser(userId uint) error {
-	today := time.Now().Truncate(24 * time.Hour)
+// ExcuseProgress marks the progress of a user habit on day as skipped or
+// frozen, creating it when nothing was logged that day.
+func (r *HabitRepo) ExcuseProgress(db *gorm.DB, userHabitId uint, day time.Time, status model.ProgressStatus) (*model.HabitProgress, error) {
+	var uh model.UserHabit
+	if err := db.Preload("User").Where("id = ?", userHabitId).First(&uh).Error; err != nil {
+		r.logger.Error("failed to get user habit", err)
+		return nil, err
+	}
+
+	ph := model.HabitProgress{
+		UserHabitID: uh.ID,
+		Date:        day,
+		RestDay:     !uh.Schedule.IsDueOn(day),
+	}
+
+	err := db.Where("user_habit_id = ? AND date = ?", userHabitId, day).FirstOrCreate(&ph).Error
+	if err != nil {
+		r.logger.Error("failed to get habit progress", err)
+		return nil, err
+	}
+
+	if ph.IsCompleted {
+		return nil, domainErr.ErrProgressCompleted
+	}
 
+	ph.Status = status
+	if err := db.Model(&ph).Update("status", status).Error; err != nil {
+		r.logger.Error("failed to excuse habit progress", err)
+		return nil, err
+	}
+
+	return &ph, nil
+}
+
+// EnsureTodayProgressForUser creates empty progress for today, the user's
+// current calendar day, for each habit due on it.
+func (r *HabitRepo) EnsureTodayProgressForUser(userId uint, today time.Time) error {
 	var userHabits []model.UserHabit
-	if err := r.db.Where("user_id = ?", userId).Find(&userHabits).Error; err != nil {
+	if err := r.db.Where("user_id = ? AND archived_at IS NULL", userId).Find(&userHabits).Error; err != nil {
 		return err
 	}
 
@@ -230,6 +483,9 @@ func (r *HabitRepo) EnsureTodayProgressForUser(userId uint) error {
 
 	var records []model.HabitProgress
 	for _, uh := range userHabits {
+		if !uh.Schedule.IsDueOn(today) {
+			continue
+		}
 		records = append(records, model.HabitProgress{
 			UserHabitID: uh.ID,
 			Date:        today,
@@ -238,6 +494,10 @@ func (r *HabitRepo) EnsureTodayProgressForUser(userId uint) error {
 		})
 	}
 
+	if len(records) == 0 {
+		return nil
+	}
+
 	err := r.db.
 		Model(&model.HabitProgress{}).
 		Clauses(clause.OnConflict{
@@ -254,10 +514,10 @@ func (r *HabitRepo) EnsureTodayProgressForUser(userId uint) error {
 	return nil
 }
 
-func (r *HabitRepo) GetTodayHabitProgress(userHabitId uint) (*model.HabitProgress, error) {
+func (r *HabitRepo) GetTodayHabitProgress(userHabitId uint, today time.Time) (*model.HabitProgress, error) {
 	var habits model.HabitProgress
 	err := r.db.Where("user_habit_id = ?", userHabitId).
-		Where("date = ?", time.Now().Truncate(24*time.Hour)).
+		Where("date = ?", today).
 		Find(&habits).Error
 
 	if err != nil {
@@ -268,10 +528,10 @@ func (r *HabitRepo) GetTodayHabitProgress(userHabitId uint) (*model.HabitProgres
 	return &habits, nil
 }
 
-func (r *HabitRepo) GetTodayHabitProgresses(userHabitId []uint) ([]model.HabitProgress, error) {
+func (r *HabitRepo) GetTodayHabitProgresses(userHabitId []uint, today time.Time) ([]model.HabitProgress, error) {
 	var habits []model.HabitProgress
 	err := r.db.Where("user_habit_id IN ?", userHabitId).
-		Where("date = ?", time.Now().Truncate(24*time.Hour)).
+		Where("date = ?", today).
 		Find(&habits).Error
 
 	if err != nil {
@@ -302,12 +562,18 @@ func (r *HabitRepo) GetUserHabitProgresses(userId uint, userHabitId uint, from,
 	return progresses, nil
 }
 
-func (r *HabitRepo) GetUserHabits(userId uint) ([]*model.UserHabit, error) {
+func (r *HabitRepo) GetUserHabits(userId uint, includeArchived bool) ([]*model.UserHabit, error) {
 	var userHabits []*model.UserHabit
-	err := r.db.Preload("Habit").
+	q := r.db.Preload("Habit").
 		Preload("Unit").
-		Where("user_id = ?", userId).
-		Find(&userHabits).Error
+		Preload("User").
+		Where("user_id = ?", userId)
+
+	if !includeArchived {
+		q = q.Where("archived_at IS NULL")
+	}
+
+	err := q.Find(&userHabits).Error
 
 	if err != nil {
 		r.logger.Error("failed to get habit", err)
@@ -317,6 +583,188 @@ func (r *HabitRepo) GetUserHabits(userId uint) ([]*model.UserHabit, error) {
 	return userHabits, nil
 }
 
+// UpdateUserHabit saves the editable settings of a user habit.
+func (r *HabitRepo) UpdateUserHabit(db *gorm.DB, uh *model.UserHabit) error {
+	err := db.Model(uh).
+		Select("unit_id", "goal", "goal_frequency",
+			"schedule_type", "schedule_weekdays", "schedule_interval", "schedule_start_date").
+		Updates(uh).Error
+
+	if err != nil {
+		r.logger.Error("failed to update user habit", err)
+		return err
+	}
+
+	return nil
+}
+
+// ConvertProgressValues multiplies every progress value of a user habit by
+// factor, used when the habit switches to another unit.
+func (r *HabitRepo) ConvertProgressValues(db *gorm.DB, userHabitId uint, factor float64) error {
+	err := db.Model(&model.HabitProgress{}).
+		Where("user_habit_id = ?", userHabitId).
+		Update("value", gorm.Expr("ROUND(CAST(value * ? AS numeric), 6)", factor)).Error
+
+	if err != nil {
+		r.logger.Error("failed to convert habit progress values", err)
+		return err
+	}
+
+	err = db.Model(&model.ProgressEntry{}).
+		Where("user_habit_id = ?", userHabitId).
+		Update("value", gorm.Expr("ROUND(CAST(value * ? AS numeric), 6)", factor)).Error
+
+	if err != nil {
+		r.logger.Error("failed to convert progress entry values", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *HabitRepo) SetUserHabitArchived(userHabitId uint, archivedAt *time.Time) error {
+	err := r.db.Model(&model.UserHabit{}).
+		Where("id = ?", userHabitId).
+		Update("archived_at", archivedAt).Error
+
+	if err != nil {
+		r.logger.Error("failed to archive user habit", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUserHabit removes a user habit together with its progress history.
+func (r *HabitRepo) DeleteUserHabit(db *gorm.DB, userHabitId uint) error {
+	if err := db.Where("user_habit_id = ?", userHabitId).Delete(&model.ProgressEntry{}).Error; err != nil {
+		r.logger.Error("failed to delete progress entries", err)
+		return err
+	}
+
+	if err := db.Where("user_habit_id = ?", userHabitId).Delete(&model.HabitProgress{}).Error; err != nil {
+		r.logger.Error("failed to delete habit progresses", err)
+		return err
+	}
+
+	if err := db.Where("user_habit_id = ?", userHabitId).Delete(&model.Reminder{}).Error; err != nil {
+		r.logger.Error("failed to delete reminders", err)
+		return err
+	}
+
+	if err := db.Where("user_habit_id = ?", userHabitId).Delete(&model.MilestoneAward{}).Error; err != nil {
+		r.logger.Error("failed to delete milestone awards", err)
+		return err
+	}
+
+	if err := db.Delete(&model.UserHabit{}, userHabitId).Error; err != nil {
+		r.logger.Error("failed to delete user habit", err)
+		return err
+	}
+
+	return nil
+}
+
+// RecalculateCompletion re-evaluates the completion of a user habit's progress
+// on day against its current goal. It returns nil when nothing was logged.
+func (r *HabitRepo) RecalculateCompletion(db *gorm.DB, userHabitId uint, day time.Time) (*model.HabitProgress, error) {
+	var uh model.UserHabit
+	if err := db.Preload("User").Where("id = ?", userHabitId).First(&uh).Error; err != nil {
+		r.logger.Error("failed to get user habit", err)
+		return nil, err
+	}
+
+	ph, err := r.getProgressByDate(db, userHabitId, day)
+	if err != nil || ph == nil {
+		return nil, err
+	}
+
+	if err := r.refreshCompletion(db, &uh, ph); err != nil {
+		return nil, err
+	}
+
+	return ph, nil
+}
+
+// GetProgressEntries returns the entries logged for a user habit on day.
+func (r *HabitRepo) GetProgressEntries(userHabitId uint, day time.Time) (*model.HabitProgress, error) {
+	ph, err := r.GetProgressByDate(userHabitId, day)
+	if err != nil || ph == nil {
+		return ph, err
+	}
+
+	if err := r.db.Where("habit_progress_id = ?", ph.ID).Order("logged_at").Find(&ph.Entries).Error; err != nil {
+		r.logger.Error("failed to get progress entries", err)
+		return nil, err
+	}
+
+	return ph, nil
+}
+
+func (r *HabitRepo) GetProgressEntry(userHabitId uint, entryId uint) (*model.ProgressEntry, error) {
+	var entry model.ProgressEntry
+	err := r.db.Select("progress_entries.*, habit_progresses.date AS date").
+		Joins("JOIN habit_progresses ON habit_progresses.id = progress_entries.habit_progress_id").
+		Where("progress_entries.id = ? AND progress_entries.user_habit_id = ?", entryId, userHabitId).
+		First(&entry).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErr.ErrEntryNotFound
+		}
+		r.logger.Error("failed to get progress entry", err)
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// UpdateProgressEntry saves an edited entry and returns its recalculated daily
+// progress. Pass the transaction the change belongs to, or the repository's
+// DB.
+func (r *HabitRepo) UpdateProgressEntry(db *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error) {
+	return r.changeProgressEntry(db, entry, func(tx *gorm.DB) error {
+		return tx.Model(entry).Select("value", "note").Updates(entry).Error
+	})
+}
+
+// DeleteProgressEntry removes an entry and returns its recalculated daily
+// progress. Pass the transaction the change belongs to, or the repository's
+// DB.
+func (r *HabitRepo) DeleteProgressEntry(db *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error) {
+	return r.changeProgressEntry(db, entry, func(tx *gorm.DB) error {
+		return tx.Delete(entry).Error
+	})
+}
+
+func (r *HabitRepo) changeProgressEntry(db *gorm.DB, entry *model.ProgressEntry, change func(tx *gorm.DB) error) (*model.HabitProgress, error) {
+	var uh model.UserHabit
+	var ph model.HabitProgress
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("User").Where("id = ?", entry.UserHabitID).First(&uh).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ?", entry.HabitProgressID).First(&ph).Error; err != nil {
+			return err
+		}
+
+		if err := change(tx); err != nil {
+			return err
+		}
+
+		return r.recalculateProgress(tx, &uh, &ph)
+	})
+
+	if err != nil {
+		r.logger.Error("failed to change progress entry", err)
+		return nil, err
+	}
+
+	return &ph, nil
+}
+
 func (r *HabitRepo) GetDB() *gorm.DB {
 	return r.db
 }
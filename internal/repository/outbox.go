@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"routinist/internal/domain/model"
+	"routinist/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepo struct {
+	db     *gorm.DB
+	logger *logger.Logger
+}
+
+func NewOutboxRepo(db *gorm.DB, logger *logger.Logger) *OutboxRepo {
+	return &OutboxRepo{db, logger}
+}
+
+// AppendEvents adds events to the outbox. Pass the transaction of the change
+// the events describe.
+func (r *OutboxRepo) AppendEvents(db *gorm.DB, events []model.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	if err := db.Create(&events).Error; err != nil {
+		r.logger.Error("failed to append outbox events", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDueEvents returns unpublished events whose next attempt is due, oldest
+// first.
+func (r *OutboxRepo) GetDueEvents(now time.Time, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := r.db.Where("published_at IS NULL AND next_attempt_at <= ?", now).
+		Order("id").
+		Limit(limit).
+		Find(&events).Error
+
+	if err != nil {
+		r.logger.Error("failed to get due outbox events", err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// ClaimEvent postpones the event's next attempt to until, so that no other
+// relay picks it up meanwhile. It returns false when another relay claimed it
+// first.
+func (r *OutboxRepo) ClaimEvent(event *model.OutboxEvent, until time.Time) (bool, error) {
+	result := r.db.Model(&model.OutboxEvent{}).
+		Where("id = ? AND published_at IS NULL AND next_attempt_at = ?", event.ID, event.NextAttemptAt).
+		Update("next_attempt_at", until)
+
+	if result.Error != nil {
+		r.logger.Error("failed to claim outbox event", result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateEvent saves the outcome of relaying an event.
+func (r *OutboxRepo) UpdateEvent(event *model.OutboxEvent) error {
+	err := r.db.Model(event).
+		Select("attempts", "next_attempt_at", "last_error", "published_at").
+		Updates(event).Error
+
+	if err != nil {
+		r.logger.Error("failed to update outbox event", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetProcessedConsumers returns the subscribers that already handled an event.
+func (r *OutboxRepo) GetProcessedConsumers(eventId string) ([]string, error) {
+	var consumers []string
+	err := r.db.Model(&model.ProcessedEvent{}).
+		Where("event_id = ?", eventId).
+		Pluck("consumer", &consumers).Error
+
+	if err != nil {
+		r.logger.Error("failed to get processed events", err)
+		return nil, err
+	}
+
+	return consumers, nil
+}
+
+func (r *OutboxRepo) MarkProcessed(consumer string, eventId string) error {
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ProcessedEvent{Consumer: consumer, EventID: eventId, ProcessedAt: time.Now()}).Error
+
+	if err != nil {
+		r.logger.Error("failed to mark event processed", err)
+		return err
+	}
+
+	return nil
+}
+
+// RecordProcessed marks the event processed by the consumer in db's
+// transaction, so that the marker commits with the consumer's changes. It
+// returns false when the event was already processed; a concurrent
+// transaction recording it first makes this one wait for its outcome.
+func (r *OutboxRepo) RecordProcessed(db *gorm.DB, consumer string, eventId string) (bool, error) {
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ProcessedEvent{Consumer: consumer, EventID: eventId, ProcessedAt: time.Now()})
+
+	if result.Error != nil {
+		r.logger.Error("failed to record processed event", result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *OutboxRepo) GetDB() *gorm.DB {
+	return r.db
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"errors"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReminderRepo struct {
+	db     *gorm.DB
+	logger *logger.Logger
+}
+
+func NewReminderRepo(db *gorm.DB, logger *logger.Logger) *ReminderRepo {
+	return &ReminderRepo{db, logger}
+}
+
+func (r *ReminderRepo) GetReminders(userHabitId uint) ([]model.Reminder, error) {
+	var reminders []model.Reminder
+	err := r.db.Where("user_habit_id = ?", userHabitId).
+		Order("time_of_day").
+		Find(&reminders).Error
+
+	if err != nil {
+		r.logger.Error("failed to get reminders", err)
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func (r *ReminderRepo) GetReminder(userHabitId uint, reminderId uint) (*model.Reminder, error) {
+	var reminder model.Reminder
+	err := r.db.Where("id = ? AND user_habit_id = ?", reminderId, userHabitId).First(&reminder).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErr.ErrReminderNotFound
+		}
+		r.logger.Error("failed to get reminder", err)
+		return nil, err
+	}
+
+	return &reminder, nil
+}
+
+func (r *ReminderRepo) CreateReminder(reminder *model.Reminder) error {
+	if err := r.db.Create(reminder).Error; err != nil {
+		r.logger.Error("failed to create reminder", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateReminder saves the reminder's time, whether it is enabled and when it
+// is next due. A new time may fall later today, so the reminder may be sent
+// again.
+func (r *ReminderRepo) UpdateReminder(reminder *model.Reminder) error {
+	err := r.db.Model(reminder).
+		Select("time_of_day", "enabled", "last_sent_on", "next_due_at").
+		Updates(reminder).Error
+
+	if err != nil {
+		r.logger.Error("failed to update reminder", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *ReminderRepo) DeleteReminder(reminder *model.Reminder) error {
+	if err := r.db.Delete(reminder).Error; err != nil {
+		r.logger.Error("failed to delete reminder", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDueReminders returns up to limit enabled reminders of habits that are
+// not archived and whose next due time is not after now, earliest first, with
+// the habit and its user loaded.
+func (r *ReminderRepo) GetDueReminders(now time.Time, limit int) ([]model.Reminder, error) {
+	var reminders []model.Reminder
+	err := r.db.Preload("UserHabit.User").
+		Preload("UserHabit.Habit").
+		Preload("UserHabit.Unit").
+		Joins("JOIN user_habits ON user_habits.id = reminders.user_habit_id").
+		Where("reminders.enabled = ?", true).
+		Where("reminders.next_due_at <= ?", now.UTC()).
+		Where("user_habits.archived_at IS NULL").
+		Order("reminders.next_due_at").
+		Limit(limit).
+		Find(&reminders).Error
+
+	if err != nil {
+		r.logger.Error("failed to get due reminders", err)
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+func (r *ReminderRepo) SetNextDueAt(reminderId uint, nextDueAt time.Time) error {
+	err := r.db.Model(&model.Reminder{}).
+		Where("id = ?", reminderId).
+		UpdateColumn("next_due_at", nextDueAt.UTC()).Error
+
+	if err != nil {
+		r.logger.Error("failed to set reminder due time", err)
+		return err
+	}
+
+	return nil
+}
+
+// ClaimReminder marks the reminder as sent on day and moves it to its next
+// due time. It returns false when it already was sent, so that each reminder
+// is sent once a day even with several schedulers running.
+func (r *ReminderRepo) ClaimReminder(reminderId uint, day time.Time, nextDueAt time.Time) (bool, error) {
+	result := r.db.Model(&model.Reminder{}).
+		Where("id = ? AND (last_sent_on IS NULL OR last_sent_on < ?)", reminderId, day).
+		Updates(map[string]interface{}{"last_sent_on": day, "next_due_at": nextDueAt.UTC()})
+
+	if result.Error != nil {
+		r.logger.Error("failed to claim reminder", result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
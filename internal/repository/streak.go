@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"errors"
+	"routinist/internal/domain/model"
+	"routinist/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StreakRepo struct {
+	db     *gorm.DB
+	logger *logger.Logger
+}
+
+func NewStreakRepo(db *gorm.DB, logger *logger.Logger) *StreakRepo {
+	return &StreakRepo{db, logger}
+}
+
+// GetLatestStreak returns the most recent streak of a user habit, or nil when
+// the habit has never been completed.
+func (r *StreakRepo) GetLatestStreak(db *gorm.DB, userHabitId uint) (*model.Streak, error) {
+	var streak model.Streak
+	err := db.Where("user_habit_id = ?", userHabitId).
+		Order("end_date DESC").
+		First(&streak).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		r.logger.Error("failed to get latest streak", err)
+		return nil, err
+	}
+
+	return &streak, nil
+}
+
+func (r *StreakRepo) GetLatestStreaks(userHabitIds []uint) (map[uint]model.Streak, error) {
+	result := make(map[uint]model.Streak)
+	if len(userHabitIds) == 0 {
+		return result, nil
+	}
+
+	var streaks []model.Streak
+	err := r.db.Raw(`
+		SELECT DISTINCT ON (user_habit_id) *
+		FROM streaks
+		WHERE user_habit_id IN ?
+		ORDER BY user_habit_id, end_date DESC
+	`, userHabitIds).Scan(&streaks).Error
+
+	if err != nil {
+		r.logger.Error("failed to get latest streaks", err)
+		return nil, err
+	}
+
+	for _, s := range streaks {
+		result[s.UserHabitID] = s
+	}
+
+	return result, nil
+}
+
+func (r *StreakRepo) GetLongestStreaks(userHabitIds []uint) (map[uint]uint, error) {
+	result := make(map[uint]uint)
+	if len(userHabitIds) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		UserHabitID uint
+		Longest     uint
+	}
+	err := r.db.Model(&model.Streak{}).
+		Select("user_habit_id, MAX(length) AS longest").
+		Where("user_habit_id IN ?", userHabitIds).
+		Group("user_habit_id").
+		Scan(&rows).Error
+
+	if err != nil {
+		r.logger.Error("failed to get longest streaks", err)
+		return nil, err
+	}
+
+	for _, row := range rows {
+		result[row.UserHabitID] = row.Longest
+	}
+
+	return result, nil
+}
+
+func (r *StreakRepo) GetStreaks(userHabitId uint) ([]model.Streak, error) {
+	var streaks []model.Streak
+	err := r.db.Where("user_habit_id = ?", userHabitId).
+		Order("end_date DESC").
+		Find(&streaks).Error
+
+	if err != nil {
+		r.logger.Error("failed to get streaks", err)
+		return nil, err
+	}
+
+	return streaks, nil
+}
+
+// SaveStreak saves the streak. A new streak starting on the same period as an
+// existing one updates it instead, so a streak is never counted twice.
+func (r *StreakRepo) SaveStreak(db *gorm.DB, streak *model.Streak) error {
+	if streak.ID == 0 {
+		db = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_habit_id"}, {Name: "start_date"}},
+			DoUpdates: clause.AssignmentColumns([]string{"updated_at", "end_date", "length", "last_completed_date"}),
+		})
+	}
+
+	if err := db.Save(streak).Error; err != nil {
+		r.logger.Error("failed to save streak", err)
+		return err
+	}
+
+	return nil
+}
+
+// ReplaceStreaks swaps all streaks of a user habit for the given ones, used
+// when streaks are rebuilt from the progress history.
+func (r *StreakRepo) ReplaceStreaks(db *gorm.DB, userHabitId uint, streaks []model.Streak) error {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_habit_id = ?", userHabitId).Delete(&model.Streak{}).Error; err != nil {
+			return err
+		}
+
+		if len(streaks) == 0 {
+			return nil
+		}
+
+		return tx.Create(&streaks).Error
+	})
+
+	if err != nil {
+		r.logger.Error("failed to replace streaks", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *StreakRepo) DeleteStreaks(db *gorm.DB, userHabitId uint) error {
+	if err := db.Where("user_habit_id = ?", userHabitId).Delete(&model.Streak{}).Error; err != nil {
+		r.logger.Error("failed to delete streaks", err)
+		return err
+	}
+
+	return nil
+}
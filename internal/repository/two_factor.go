@@ -0,0 +1,197 @@
+package repository
+
+import (
+	goerrors "errors"
+	"routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GetTwoFactor returns nil without an error when the user has not enrolled
+// an authenticator.
+func (rp *AuthRepo) GetTwoFactor(userId uint) (*model.TwoFactor, error) {
+	var twoFactor model.TwoFactor
+	result := rp.db.Where("user_id = ?", userId).Limit(1).Find(&twoFactor)
+
+	if result.Error != nil {
+		rp.logger.Error("failed to get two-factor", result.Error)
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+
+	return &twoFactor, nil
+}
+
+func (rp *AuthRepo) CreateTwoFactor(db *gorm.DB, twoFactor *model.TwoFactor) error {
+	if err := db.Create(twoFactor).Error; err != nil {
+		rp.logger.Error("failed to create two-factor", err)
+		return err
+	}
+
+	return nil
+}
+
+// EnableTwoFactor turns on the pending authenticator, remembering the time
+// step of the code it was confirmed with.
+func (rp *AuthRepo) EnableTwoFactor(db *gorm.DB, userId uint, step int64) error {
+	result := db.Model(&model.TwoFactor{}).
+		Where("user_id = ? AND enabled_at IS NULL", userId).
+		Updates(map[string]interface{}{
+			"enabled_at": time.Now(),
+			"last_step":  step,
+		})
+
+	if result.Error != nil {
+		rp.logger.Error("failed to enable two-factor", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrTwoFactorEnabled
+	}
+
+	return nil
+}
+
+// UseTOTPStep records that a code of the given time step was accepted. It
+// fails with ErrInvalidTwoFactorCode unless the step is later than the last
+// one, so a code cannot be replayed.
+func (rp *AuthRepo) UseTOTPStep(userId uint, step int64) error {
+	result := rp.db.Model(&model.TwoFactor{}).
+		Where("user_id = ? AND last_step < ?", userId, step).
+		Update("last_step", step)
+
+	if result.Error != nil {
+		rp.logger.Error("failed to use TOTP step", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// DeleteTwoFactor removes the user's authenticator and recovery codes.
+func (rp *AuthRepo) DeleteTwoFactor(db *gorm.DB, userId uint) error {
+	if err := db.Where("user_id = ?", userId).Delete(&model.RecoveryCode{}).Error; err != nil {
+		rp.logger.Error("failed to delete recovery codes", err)
+		return err
+	}
+
+	if err := db.Where("user_id = ?", userId).Delete(&model.TwoFactor{}).Error; err != nil {
+		rp.logger.Error("failed to delete two-factor", err)
+		return err
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes invalidates the user's recovery codes in favour of
+// new ones.
+func (rp *AuthRepo) ReplaceRecoveryCodes(db *gorm.DB, userId uint, codeHashes []string) error {
+	if err := db.Where("user_id = ?", userId).Delete(&model.RecoveryCode{}).Error; err != nil {
+		rp.logger.Error("failed to delete recovery codes", err)
+		return err
+	}
+
+	codes := make([]model.RecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, model.RecoveryCode{UserID: userId, CodeHash: hash})
+	}
+
+	if err := db.Create(&codes).Error; err != nil {
+		rp.logger.Error("failed to create recovery codes", err)
+		return err
+	}
+
+	return nil
+}
+
+// UseRecoveryCode spends one of the user's recovery codes. It fails with
+// ErrInvalidTwoFactorCode when no unused code matches.
+func (rp *AuthRepo) UseRecoveryCode(userId uint, codeHash string) error {
+	result := rp.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		rp.logger.Error("failed to use recovery code", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+func (rp *AuthRepo) CreateLoginChallenge(challenge *model.LoginChallenge) error {
+	if err := rp.db.Create(challenge).Error; err != nil {
+		rp.logger.Error("failed to create login challenge", err)
+		return err
+	}
+
+	return nil
+}
+
+func (rp *AuthRepo) GetLoginChallenge(tokenHash string) (*model.LoginChallenge, error) {
+	var challenge model.LoginChallenge
+	err := rp.db.Where("token_hash = ?", tokenHash).First(&challenge).Error
+
+	if err != nil {
+		if goerrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.ErrInvalidChallenge
+		}
+		rp.logger.Error("failed to get login challenge", err)
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// RecordChallengeAttempt counts a code submitted for the challenge. It fails
+// with ErrInvalidChallenge once maxAttempts codes were tried, so that codes
+// cannot be guessed.
+func (rp *AuthRepo) RecordChallengeAttempt(challengeId uint, maxAttempts int) error {
+	result := rp.db.Model(&model.LoginChallenge{}).
+		Where("id = ? AND attempts < ? AND used_at IS NULL", challengeId, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+
+	if result.Error != nil {
+		rp.logger.Error("failed to record login challenge attempt", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidChallenge
+	}
+
+	return nil
+}
+
+// UseLoginChallenge marks the challenge as used. It fails with
+// ErrInvalidChallenge when it was used in the meantime.
+func (rp *AuthRepo) UseLoginChallenge(challengeId uint) error {
+	result := rp.db.Model(&model.LoginChallenge{}).
+		Where("id = ? AND used_at IS NULL", challengeId).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		rp.logger.Error("failed to use login challenge", result.Error)
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errors.ErrInvalidChallenge
+	}
+
+	return nil
+}
@@ -2,8 +2,11 @@ package repository
 
 import (
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"routinist/internal/domain/errors"
 	"routinist/internal/domain/model"
 	"routinist/pkg/logger"
+	"time"
 )
 
 type UserRepo struct {
@@ -17,20 +20,228 @@ func NewUserRepo(db *gorm.DB, logger *logger.Logger) *UserRepo {
 	}
 }
 
-func (rp *UserRepo) UpdateMilestone(userId uint, milestone uint) (uint, error) {
+func (rp *UserRepo) GetUser(userId uint) (*model.User, error) {
 	var user model.User
-	err := rp.db.Where("id = ?", userId).First(&user).Error
+	if err := rp.db.Where("id = ?", userId).First(&user).Error; err != nil {
+		rp.logger.Error("failed to get user", err)
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (rp *UserRepo) UpdateTimeZone(userId uint, timeZone string) error {
+	err := rp.db.Model(&model.User{}).
+		Where("id = ?", userId).
+		Update("time_zone", timeZone).Error
+
+	if err != nil {
+		rp.logger.Error("failed to update time zone", err)
+		return err
+	}
+
+	return nil
+}
+
+// RescheduleReminders makes the user's reminders due at once, so that the
+// scheduler works out their next time in the user's new time zone.
+func (rp *UserRepo) RescheduleReminders(userId uint) error {
+	err := rp.db.Model(&model.Reminder{}).
+		Where("user_habit_id IN (?)", rp.db.Model(&model.UserHabit{}).Select("id").Where("user_id = ?", userId)).
+		UpdateColumn("next_due_at", gorm.Expr("CURRENT_TIMESTAMP")).Error
+
+	if err != nil {
+		rp.logger.Error("failed to reschedule reminders", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpdateUser saves the editable profile fields and preferences of a user.
+func (rp *UserRepo) UpdateUser(user *model.User) error {
+	err := rp.db.Model(user).
+		Select("name", "gender", "time_zone",
+			"pref_week_start", "pref_unit_system", "pref_email_notifications", "pref_push_notifications").
+		Updates(user).Error
+
+	if err != nil {
+		rp.logger.Error("failed to update user", err)
+		return err
+	}
+
+	return nil
+}
+
+// AwardMilestone gives the user a milestone for completing the goal period
+// of a user habit starting at period, and returns the new milestone count.
+// It returns zero when the period already earned one. The user's row stays
+// locked until db's transaction ends.
+func (rp *UserRepo) AwardMilestone(db *gorm.DB, userId uint, userHabitId uint, period time.Time) (uint, error) {
+	award := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.MilestoneAward{
+		UserID:      userId,
+		UserHabitID: userHabitId,
+		PeriodStart: period,
+	})
+	if award.Error != nil {
+		rp.logger.Error("failed to award milestone", award.Error)
+		return 0, award.Error
+	}
+
+	if award.RowsAffected == 0 {
+		return 0, nil
+	}
+
+	var user model.User
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", userId).First(&user).Error
 	if err != nil {
 		return 0, err
 	}
 
-	rp.logger.Info("User milestone before update: ", user)
-	user.Milestone = user.Milestone + milestone
-	rp.logger.Info("User milestone updated: ", user)
-	err = rp.db.Save(&user).Error
+	before := user.Milestone / model.MilestonesPerFreezeToken
+	user.Milestone++
+	user.FreezeTokens += user.Milestone/model.MilestonesPerFreezeToken - before
+
+	err = db.Model(&user).Select("milestone", "freeze_tokens").Updates(&user).Error
 	if err != nil {
+		rp.logger.Error("failed to update milestone", err)
 		return 0, err
 	}
 
 	return user.Milestone, nil
 }
+
+// UseFreezeToken spends one of the user's streak freeze tokens and returns how
+// many are left.
+func (rp *UserRepo) UseFreezeToken(db *gorm.DB, userId uint) (uint, error) {
+	result := db.Model(&model.User{}).
+		Where("id = ? AND freeze_tokens > 0", userId).
+		Update("freeze_tokens", gorm.Expr("freeze_tokens - 1"))
+
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return 0, errors.ErrNoFreezeTokens
+	}
+
+	var user model.User
+	if err := db.Where("id = ?", userId).First(&user).Error; err != nil {
+		return 0, err
+	}
+
+	return user.FreezeTokens, nil
+}
+
+// IncrementTokenVersion invalidates every access token issued to the user so
+// far.
+func (rp *UserRepo) IncrementTokenVersion(db *gorm.DB, userId uint) error {
+	err := db.Model(&model.User{}).
+		Where("id = ?", userId).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+
+	if err != nil {
+		rp.logger.Error("failed to increment token version", err)
+		return err
+	}
+
+	return nil
+}
+
+// DeleteUser removes a user and everything recorded about them. Every table
+// holding user data must be cleared here, children before their parents.
+func (rp *UserRepo) DeleteUser(db *gorm.DB, userId uint) error {
+	userHabits := func() *gorm.DB {
+		return db.Model(&model.UserHabit{}).Select("id").Where("user_id = ?", userId)
+	}
+	customHabits := func() *gorm.DB {
+		return db.Model(&model.Habit{}).Select("id").Where("owner_id = ?", userId)
+	}
+
+	steps := []struct {
+		table  string
+		delete func() error
+	}{
+		{"progress entries", func() error {
+			return db.Where("user_habit_id IN (?)", userHabits()).Delete(&model.ProgressEntry{}).Error
+		}},
+		{"habit progresses", func() error {
+			return db.Where("user_habit_id IN (?)", userHabits()).Delete(&model.HabitProgress{}).Error
+		}},
+		{"streaks", func() error {
+			return db.Where("user_habit_id IN (?)", userHabits()).Delete(&model.Streak{}).Error
+		}},
+		{"reminders", func() error {
+			return db.Where("user_habit_id IN (?)", userHabits()).Delete(&model.Reminder{}).Error
+		}},
+		{"milestone awards", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.MilestoneAward{}).Error
+		}},
+		{"user habits", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.UserHabit{}).Error
+		}},
+		{"custom habit units", func() error {
+			return db.Where("habit_id IN (?)", customHabits()).Delete(&model.HabitUnit{}).Error
+		}},
+		{"custom habits", func() error {
+			return db.Where("owner_id = ?", userId).Delete(&model.Habit{}).Error
+		}},
+		{"refresh tokens", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.RefreshToken{}).Error
+		}},
+		{"password reset tokens", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.PasswordResetToken{}).Error
+		}},
+		{"email verification tokens", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.EmailVerificationToken{}).Error
+		}},
+		{"external identities", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.ExternalIdentity{}).Error
+		}},
+		{"recovery codes", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.RecoveryCode{}).Error
+		}},
+		{"two-factor authenticators", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.TwoFactor{}).Error
+		}},
+		{"login challenges", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.LoginChallenge{}).Error
+		}},
+		{"push deliveries", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.PushDelivery{}).Error
+		}},
+		{"devices", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.Device{}).Error
+		}},
+		{"webhook deliveries", func() error {
+			subscriptions := db.Model(&model.WebhookSubscription{}).Select("id").Where("user_id = ?", userId)
+			return db.Where("subscription_id IN (?)", subscriptions).Delete(&model.WebhookDelivery{}).Error
+		}},
+		{"webhook subscriptions", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.WebhookSubscription{}).Error
+		}},
+		{"achievements", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.UserAchievement{}).Error
+		}},
+		{"processed events", func() error {
+			outbox := db.Model(&model.OutboxEvent{}).Select("event_id").Where("user_id = ?", userId)
+			return db.Where("event_id IN (?)", outbox).Delete(&model.ProcessedEvent{}).Error
+		}},
+		{"outbox events", func() error {
+			return db.Where("user_id = ?", userId).Delete(&model.OutboxEvent{}).Error
+		}},
+		{"user", func() error {
+			return db.Where("id = ?", userId).Delete(&model.User{}).Error
+		}},
+	}
+
+	for _, step := range steps {
+		if err := step.delete(); err != nil {
+			rp.logger.Error("failed to delete "+step.table, err)
+			return err
+		}
+	}
+
+	return nil
+}
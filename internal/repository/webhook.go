@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"errors"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/pkg/logger"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepo struct {
+	db     *gorm.DB
+	logger *logger.Logger
+}
+
+func NewWebhookRepo(db *gorm.DB, logger *logger.Logger) *WebhookRepo {
+	return &WebhookRepo{db, logger}
+}
+
+func (r *WebhookRepo) CreateSubscription(subscription *model.WebhookSubscription) error {
+	if err := r.db.Create(subscription).Error; err != nil {
+		r.logger.Error("failed to create webhook subscription", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *WebhookRepo) GetSubscriptions(userId uint) ([]model.WebhookSubscription, error) {
+	var subscriptions []model.WebhookSubscription
+	err := r.db.Where("user_id = ?", userId).Order("id").Find(&subscriptions).Error
+
+	if err != nil {
+		r.logger.Error("failed to get webhook subscriptions", err)
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (r *WebhookRepo) GetSubscription(userId uint, subscriptionId uint) (*model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	err := r.db.Where("id = ? AND user_id = ?", subscriptionId, userId).First(&subscription).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domainErr.ErrWebhookNotFound
+		}
+		r.logger.Error("failed to get webhook subscription", err)
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+// DeleteSubscription removes a subscription with its delivery log.
+func (r *WebhookRepo) DeleteSubscription(db *gorm.DB, subscriptionId uint) error {
+	if err := db.Where("subscription_id = ?", subscriptionId).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		r.logger.Error("failed to delete webhook deliveries", err)
+		return err
+	}
+
+	if err := db.Delete(&model.WebhookSubscription{}, subscriptionId).Error; err != nil {
+		r.logger.Error("failed to delete webhook subscription", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *WebhookRepo) CreateDeliveries(db *gorm.DB, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	// An event queued again keeps its existing deliveries
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+	if err != nil {
+		r.logger.Error("failed to create webhook deliveries", err)
+		return err
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest deliveries of a subscription, newest
+// first, optionally only those with status.
+func (r *WebhookRepo) GetDeliveries(subscriptionId uint, status model.DeliveryStatus, limit int) ([]model.WebhookDelivery, error) {
+	query := r.db.Where("subscription_id = ?", subscriptionId)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var deliveries []model.WebhookDelivery
+	err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error
+
+	if err != nil {
+		r.logger.Error("failed to get webhook deliveries", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// GetDueDeliveries returns pending deliveries whose next attempt is due,
+// oldest first, with their subscription loaded.
+func (r *WebhookRepo) GetDueDeliveries(now time.Time, limit int) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := r.db.Preload("Subscription").
+		Where("status = ? AND next_attempt_at <= ?", model.DeliveryPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&deliveries).Error
+
+	if err != nil {
+		r.logger.Error("failed to get due webhook deliveries", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDelivery postpones the delivery's next attempt to until, so that no
+// other worker picks it up meanwhile. It returns false when another worker
+// claimed it first.
+func (r *WebhookRepo) ClaimDelivery(delivery *model.WebhookDelivery, until time.Time) (bool, error) {
+	result := r.db.Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, model.DeliveryPending, delivery.NextAttemptAt).
+		Update("next_attempt_at", until)
+
+	if result.Error != nil {
+		r.logger.Error("failed to claim webhook delivery", result.Error)
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// UpdateDelivery saves the outcome of an attempt.
+func (r *WebhookRepo) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	err := r.db.Model(delivery).
+		Select("status", "attempts", "next_attempt_at", "last_status_code", "last_error", "delivered_at").
+		Updates(delivery).Error
+
+	if err != nil {
+		r.logger.Error("failed to update webhook delivery", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *WebhookRepo) GetDB() *gorm.DB {
+	return r.db
+}
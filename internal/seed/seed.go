@@ -12,6 +12,7 @@ func Seed(db *gorm.DB, l *logger.Logger) {
 	seedUnits(db, l)
 	seedHabits(db, l)
 	seedHabitUnits(db, l)
+	seedAchievements(db, l)
 }
 
 func seedUnits(db *gorm.DB, l *logger.Logger) {
@@ -140,3 +141,26 @@ func seedHabitUnits(db *gorm.DB, l *logger.Logger) {
 
 	l.Info("Seeded HabitUnits")
 }
+
+// seedAchievements adds new achievements and updates existing ones by key, so
+// that changing the list below reaches databases that were already seeded.
+func seedAchievements(db *gorm.DB, l *logger.Logger) {
+	achievements := []model.Achievement{
+		{Key: "first_goal", Name: "First Step", Description: "Reach a goal for the first time", Icon: "🌱", Rule: model.RuleCompletions, Threshold: 1},
+		{Key: "first_weekly_goal", Name: "First Weekly Goal", Description: "Reach a weekly goal for the first time", Icon: "📅", Rule: model.RuleCompletions, Threshold: 1, Frequency: model.FrequencyWeekly},
+		{Key: "completions_100", Name: "Century", Description: "Reach 100 goals", Icon: "💯", Rule: model.RuleCompletions, Threshold: 100},
+		{Key: "streak_7_days", Name: "7-Day Streak", Description: "Reach a daily goal 7 days in a row", Icon: "🔥", Rule: model.RuleStreak, Threshold: 7, Frequency: model.FrequencyDaily},
+		{Key: "streak_30_days", Name: "30-Day Streak", Description: "Reach a daily goal 30 days in a row", Icon: "🏆", Rule: model.RuleStreak, Threshold: 30, Frequency: model.FrequencyDaily},
+		{Key: "perfect_day", Name: "Perfect Day", Description: "Get all habits done in a day", Icon: "⭐", Rule: model.RulePerfectDay},
+	}
+
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "name", "description", "icon", "rule", "threshold", "frequency"}),
+	}).Create(&achievements).Error
+
+	if err != nil {
+		l.Fatal("failed to seed achievements: %v", err)
+	}
+	l.Info("Seeded achievements")
+}
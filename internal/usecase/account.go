@@ -0,0 +1,162 @@
+package usecase
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/dto/request"
+	"time"
+)
+
+// reauthMaxAge is how recently a user without a password must have signed in
+// with their identity provider to confirm an account change.
+const reauthMaxAge = 5 * time.Minute
+
+// reauthenticate checks that the user is present before an account change:
+// with their password, or, for accounts without one, with an ID token from a
+// linked provider they signed in to within reauthMaxAge.
+func (uc *authUseCase) reauthenticate(userId uint, reauth request.ReauthRequestDTO) error {
+	user, err := uc.userRepo.GetUser(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	if user.Password != "" {
+		return uc.repo.CheckPassword(userId, reauth.Password)
+	}
+
+	return uc.checkFreshIDToken(userId, reauth.Provider, reauth.IDToken)
+}
+
+// checkFreshIDToken fails unless rawIDToken is a valid ID token of an
+// identity linked to the user, issued for a sign-in within reauthMaxAge.
+func (uc *authUseCase) checkFreshIDToken(userId uint, provider string, rawIDToken string) error {
+	if provider == "" || rawIDToken == "" {
+		return domainErr.ErrReauthRequired
+	}
+
+	verifier, ok := uc.providers[provider]
+	if !ok {
+		return domainErr.ErrUnknownProvider
+	}
+
+	idToken, err := verifier.Verify(rawIDToken)
+	if err != nil {
+		uc.logger.Warn("rejected %s ID token: %v", provider, err)
+		return domainErr.ErrInvalidIDToken
+	}
+
+	identity, err := uc.repo.GetExternalIdentity(provider, idToken.Subject)
+	if err != nil {
+		return err
+	}
+
+	if identity == nil || identity.UserID != userId {
+		return domainErr.ErrInvalidIDToken
+	}
+
+	if time.Since(idToken.AuthTime) > reauthMaxAge {
+		return domainErr.ErrReauthRequired
+	}
+
+	return nil
+}
+
+// ChangePassword replaces the user's password after reauthenticating them.
+// Every other session is logged out.
+func (uc *authUseCase) ChangePassword(userId uint, sessionId string, reauth request.ReauthRequestDTO, password string) error {
+	if err := uc.reauthenticate(userId, reauth); err != nil {
+		return err
+	}
+
+	return uc.replacePassword(userId, sessionId, password)
+}
+
+// SetPassword adds a password to an account created through an identity
+// provider, so that the user can also log in with their email. Every other
+// session is logged out.
+func (uc *authUseCase) SetPassword(userId uint, sessionId string, req *request.SetPasswordRequestDTO) error {
+	user, err := uc.userRepo.GetUser(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	if user.Password != "" {
+		return domainErr.ErrPasswordAlreadySet
+	}
+
+	if err := uc.checkFreshIDToken(userId, req.Provider, req.IDToken); err != nil {
+		return err
+	}
+
+	return uc.replacePassword(userId, sessionId, req.Password)
+}
+
+func (uc *authUseCase) replacePassword(userId uint, sessionId string, password string) error {
+	err := uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.repo.UpdatePassword(tx, userId, password); err != nil {
+			return err
+		}
+
+		return uc.repo.RevokeUserRefreshTokens(tx, userId, sessionId)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to change password: %w", err)
+	}
+
+	uc.sessions.forgetUser(userId)
+	return nil
+}
+
+// ChangeEmail sends a verification email to the new address. The account
+// keeps its current email until the new one is verified.
+func (uc *authUseCase) ChangeEmail(userId uint, reauth request.ReauthRequestDTO, email string) error {
+	if err := uc.reauthenticate(userId, reauth); err != nil {
+		return err
+	}
+
+	existing, err := uc.repo.GetUserByEmail(email)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		return domainErr.ErrEmailAlreadyExists
+	}
+
+	if err := uc.checkVerificationRate(userId); err != nil {
+		return err
+	}
+
+	user, err := uc.userRepo.GetUser(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	return uc.sendEmailVerification(user, email)
+}
+
+// DeleteAccount permanently removes the user and all of their data after
+// reauthenticating them.
+func (uc *authUseCase) DeleteAccount(userId uint, reauth request.ReauthRequestDTO) error {
+	if err := uc.reauthenticate(userId, reauth); err != nil {
+		return err
+	}
+
+	err := uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		return uc.userRepo.DeleteUser(tx, userId)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	uc.sessions.forgetUser(userId)
+	return nil
+}
@@ -0,0 +1,166 @@
+package usecase
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"routinist/internal/domain/model"
+	"routinist/internal/domain/repository"
+	"routinist/internal/dto/response"
+	"routinist/internal/events"
+	"routinist/pkg/logger"
+	"time"
+)
+
+type AchievementUseCase interface {
+	GetAchievements(userId uint) ([]response.AchievementDto, error)
+	EvaluateAchievements(e events.Event) error
+}
+
+type achievementUseCase struct {
+	repo      repository.AchievementRepository
+	habitRepo repository.HabitRepository
+	outbox    repository.OutboxRepository
+	logger    *logger.Logger
+}
+
+func NewAchievementUseCase(r repository.AchievementRepository, habitRepo repository.HabitRepository, o repository.OutboxRepository, l *logger.Logger) AchievementUseCase {
+	return &achievementUseCase{
+		repo:      r,
+		habitRepo: habitRepo,
+		outbox:    o,
+		logger:    l,
+	}
+}
+
+// GetAchievements lists every achievement, unlocked or not, with when the
+// user unlocked it.
+func (uc *achievementUseCase) GetAchievements(userId uint) ([]response.AchievementDto, error) {
+	achievements, err := uc.repo.GetAchievements()
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked, err := uc.repo.GetUserAchievements(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	unlockedAt := make(map[uint]*time.Time, len(unlocked))
+	for i := range unlocked {
+		unlockedAt[unlocked[i].AchievementID] = &unlocked[i].UnlockedAt
+	}
+
+	result := make([]response.AchievementDto, 0, len(achievements))
+	for i := range achievements {
+		result = append(result, response.ToAchievementDto(&achievements[i], unlockedAt[achievements[i].ID]))
+	}
+
+	return result, nil
+}
+
+// habitCompletedData is the data of a habit.completed event.
+type habitCompletedData struct {
+	UserHabitID uint   `json:"user_habit_id"`
+	Date        string `json:"date"`
+}
+
+// EvaluateAchievements unlocks the achievements the user meets after a
+// habit.completed event. Rules read the current state rather than the event,
+// so an unlock missed once is caught up on the next completion.
+func (uc *achievementUseCase) EvaluateAchievements(e events.Event) error {
+	var data habitCompletedData
+	if err := e.Decode(&data); err != nil {
+		return err
+	}
+
+	day, err := time.Parse(eventDateLayout, data.Date)
+	if err != nil {
+		return err
+	}
+
+	achievements, err := uc.repo.GetAchievements()
+	if err != nil {
+		return err
+	}
+
+	unlocked, err := uc.repo.GetUserAchievements(e.UserID)
+	if err != nil {
+		return err
+	}
+
+	done := make(map[uint]bool, len(unlocked))
+	for _, ua := range unlocked {
+		done[ua.AchievementID] = true
+	}
+
+	for i := range achievements {
+		a := &achievements[i]
+		if done[a.ID] {
+			continue
+		}
+
+		met, err := uc.isMet(e.UserID, a, day)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate achievement %s: %w", a.Key, err)
+		}
+
+		if met {
+			if err := uc.unlock(e.UserID, a); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (uc *achievementUseCase) isMet(userId uint, a *model.Achievement, day time.Time) (bool, error) {
+	switch a.Rule {
+	case model.RuleStreak:
+		longest, err := uc.repo.GetLongestStreak(userId, a.Frequency)
+		return longest >= a.Threshold, err
+	case model.RuleCompletions:
+		completed, err := uc.repo.GetCompletedPeriods(userId, a.Frequency)
+		return completed >= a.Threshold, err
+	case model.RulePerfectDay:
+		return uc.isPerfectDay(userId, day)
+	}
+
+	uc.logger.Warn("achievement %s has unknown rule %s", a.Key, a.Rule)
+	return false, nil
+}
+
+// isPerfectDay reports whether every habit due on day is done.
+func (uc *achievementUseCase) isPerfectDay(userId uint, day time.Time) (bool, error) {
+	habits, err := uc.habitRepo.GetTodayHabits(userId, day)
+	if err != nil || len(habits) == 0 {
+		return false, err
+	}
+
+	for i := range habits {
+		done, err := isHabitDone(uc.habitRepo, &habits[i], day)
+		if err != nil || !done {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// unlock records the achievement for the user, along with its event.
+func (uc *achievementUseCase) unlock(userId uint, a *model.Achievement) error {
+	return uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		unlocked, err := uc.repo.UnlockAchievement(tx, userId, a.ID, now)
+		if err != nil || !unlocked {
+			return err
+		}
+
+		return appendEvents(uc.outbox, tx, events.New(events.AchievementUnlocked, userId, map[string]interface{}{
+			"achievement": a.Key,
+			"name":        a.Name,
+			"unlocked_at": now.UTC(),
+		}))
+	})
+}
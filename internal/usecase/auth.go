@@ -1,48 +1,96 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
 	"gorm.io/gorm"
+	"routinist/internal/auth"
+	"routinist/internal/auth/oidc"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
 	"routinist/internal/domain/repository"
 	"routinist/internal/dto/request"
+	"routinist/internal/mail"
 	"routinist/pkg/logger"
+	"time"
 )
 
 type AuthUseCase interface {
 	Login(request *request.LoginRequestDTO) (*request.AuthResponseDTO, error)
 	Register(request *request.RegisterRequestDTO) (*request.AuthResponseDTO, error)
+	Refresh(request *request.RefreshTokenRequestDTO) (*request.AuthResponseDTO, error)
+	Logout(sessionId string) error
+	LogoutAll(userId uint) error
+	CheckSession(claims *auth.Claims) (*auth.Session, error)
+	RequestPasswordReset(email string) error
+	ConfirmPasswordReset(token string, password string) error
+	ResendEmailVerification(userId uint) error
+	ConfirmEmailVerification(token string) error
+	ChangePassword(userId uint, sessionId string, reauth request.ReauthRequestDTO, password string) error
+	SetPassword(userId uint, sessionId string, req *request.SetPasswordRequestDTO) error
+	ChangeEmail(userId uint, reauth request.ReauthRequestDTO, email string) error
+	DeleteAccount(userId uint, reauth request.ReauthRequestDTO) error
+	LoginWithProvider(provider string, req *request.ProviderLoginRequestDTO) (*request.AuthResponseDTO, error)
+	EnrollTwoFactor(userId uint) (*request.TwoFactorEnrollmentDTO, error)
+	ConfirmTwoFactor(userId uint, code string) ([]string, error)
+	DisableTwoFactor(userId uint, sessionId string, code string) error
+	RegenerateRecoveryCodes(userId uint, code string) ([]string, error)
+	LoginChallengeUser(challengeToken string) (uint, error)
+	VerifyLoginChallenge(challengeToken string, code string) (*request.AuthResponseDTO, error)
 }
 
 type authUseCase struct {
 	repo      repository.AuthRepository
 	habitRepo repository.HabitRepository
+	userRepo  repository.UserRepository
+	sessions  *sessionCache
+	mailer    mail.Mailer
+	providers map[string]oidc.TokenVerifier
 	logger    *logger.Logger
 }
 
-func NewAuthUseCase(r repository.AuthRepository, habitRepo repository.HabitRepository, l *logger.Logger) AuthUseCase {
+func NewAuthUseCase(r repository.AuthRepository, habitRepo repository.HabitRepository, userRepo repository.UserRepository, mailer mail.Mailer, providers map[string]oidc.TokenVerifier, l *logger.Logger) AuthUseCase {
 	return &authUseCase{
 		repo:      r,
 		habitRepo: habitRepo,
+		userRepo:  userRepo,
+		mailer:    mailer,
+		providers: providers,
+		sessions:  newSessionCache(sessionCacheTTL),
 		logger:    l,
 	}
 }
 
 func (uc *authUseCase) Register(req *request.RegisterRequestDTO) (*request.AuthResponseDTO, error) {
+	if req.TimeZone != "" && !model.ValidTimeZone(req.TimeZone) {
+		return nil, domainErr.ErrInvalidTimeZone
+	}
+
+	if req.Gender != "" && !model.Gender(req.Gender).IsValid() {
+		return nil, domainErr.ErrInvalidGender
+	}
+
 	var result *request.AuthResponseDTO
-	var userId uint
+	var user *model.User
 
 	db := uc.repo.GetDB()
 
 	err := db.Transaction(func(tx *gorm.DB) error {
 		var err error
 
-		result, userId, err = uc.repo.Register(tx, req)
+		user, err = uc.repo.Register(tx, req)
 		if err != nil {
 			uc.logger.Error(err)
 			return fmt.Errorf("failed to register: %w", err)
 		}
 
-		_, err = uc.habitRepo.CreateUserHabit(tx, userId, req.HabitID, nil, nil)
+		result, err = uc.issueTokens(tx, user)
+		if err != nil {
+			uc.logger.Error(err)
+			return err
+		}
+
+		_, err = uc.habitRepo.CreateUserHabit(tx, user.ID, req.HabitID, nil, nil, model.FrequencyDaily, model.Schedule{Type: model.ScheduleEveryDay})
 		if err != nil {
 			uc.logger.Error(err)
 			return fmt.Errorf("failed to create habit: %w", err)
@@ -55,21 +103,192 @@ func (uc *authUseCase) Register(req *request.RegisterRequestDTO) (*request.AuthR
 		uc.logger.Error(err)
 		return nil, err // Both will be rolled back on any error!
 	}
+
+	// The account exists either way; the user can ask for another email
+	if err := uc.sendEmailVerification(user, user.Email); err != nil {
+		uc.logger.Error(err)
+	}
+
 	return result, nil
 }
 
 func (uc *authUseCase) Login(request *request.LoginRequestDTO) (*request.AuthResponseDTO, error) {
-	token, userId, err := uc.repo.Login(request)
+	user, err := uc.repo.Login(request)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to login: %w", err)
+	}
+
+	return uc.startSession(user)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh
+// token. A token that was already rotated revokes its whole family, logging
+// out both the thief and the legitimate client.
+func (uc *authUseCase) Refresh(req *request.RefreshTokenRequestDTO) (*request.AuthResponseDTO, error) {
+	old, err := uc.repo.GetRefreshToken(auth.HashToken(req.RefreshToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if old.RevokedAt != nil {
+		return nil, uc.revokeReusedFamily(old)
+	}
+
+	if !old.IsActive(time.Now()) {
+		return nil, domainErr.ErrInvalidRefreshToken
+	}
+
+	user, err := uc.userRepo.GetUser(old.UserID)
 	if err != nil {
 		uc.logger.Error(err)
-		return token, fmt.Errorf("failed to login: %w", err)
+		return nil, err
+	}
+
+	token, err := auth.GenerateJWT(user.Email, user.ID, old.FamilyID, user.TokenVersion)
+	if err != nil {
+		return nil, domainErr.ErrFailedToGenerateJWT
 	}
 
-	err = uc.habitRepo.EnsureTodayProgressForUser(userId)
+	refresh, hash, err := auth.GenerateToken()
 	if err != nil {
+		return nil, err
+	}
+
+	next := &model.RefreshToken{
+		UserID:    old.UserID,
+		FamilyID:  old.FamilyID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	}
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		return uc.repo.RotateRefreshToken(tx, old, next)
+	})
+
+	if errors.Is(err, domainErr.ErrRefreshTokenReused) {
+		return nil, uc.revokeReusedFamily(old)
+	}
+
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return &request.AuthResponseDTO{
+		Token:        token,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (uc *authUseCase) revokeReusedFamily(token *model.RefreshToken) error {
+	uc.logger.Warn("refresh token reused, revoking family %s of user %d", token.FamilyID, token.UserID)
+
+	if err := uc.repo.RevokeRefreshTokenFamily(token.FamilyID); err != nil {
 		uc.logger.Error(err)
+		return err
+	}
+
+	return domainErr.ErrRefreshTokenReused
+}
+
+// issueTokens starts a new session for the user: an access token and the
+// first refresh token of a new family.
+func (uc *authUseCase) issueTokens(db *gorm.DB, user *model.User) (*request.AuthResponseDTO, error) {
+	sessionId, err := auth.GenerateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := auth.GenerateJWT(user.Email, user.ID, sessionId, user.TokenVersion)
+	if err != nil {
+		return nil, domainErr.ErrFailedToGenerateJWT
+	}
+
+	refresh, hash, err := auth.GenerateToken()
+	if err != nil {
 		return nil, err
 	}
 
-	return token, nil
+	err = uc.repo.CreateRefreshToken(db, &model.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  sessionId,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue refresh token: %w", err)
+	}
+
+	return &request.AuthResponseDTO{
+		Token:        token,
+		RefreshToken: refresh,
+		ExpiresIn:    int64(auth.AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Logout revokes the session the request was made with.
+func (uc *authUseCase) Logout(sessionId string) error {
+	if err := uc.repo.RevokeRefreshTokenFamily(sessionId); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to logout: %w", err)
+	}
+
+	uc.sessions.forgetSession(sessionId)
+	return nil
+}
+
+// LogoutAll revokes every session of the user, on every device.
+func (uc *authUseCase) LogoutAll(userId uint) error {
+	err := uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.userRepo.IncrementTokenVersion(tx, userId); err != nil {
+			return err
+		}
+
+		return uc.repo.RevokeUserRefreshTokens(tx, userId, "")
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to logout: %w", err)
+	}
+
+	uc.sessions.forgetUser(userId)
+	return nil
+}
+
+// CheckSession rejects access tokens whose session was logged out or revoked,
+// or that were issued before the user logged out everywhere.
+func (uc *authUseCase) CheckSession(claims *auth.Claims) (*auth.Session, error) {
+	if claims.SessionID == "" {
+		return nil, domainErr.ErrSessionRevoked
+	}
+
+	state, ok := uc.sessions.get(claims.SessionID)
+	if !ok {
+		active, err := uc.repo.IsSessionActive(claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := uc.userRepo.GetUser(claims.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		state = sessionState{
+			userId:   user.ID,
+			version:  user.TokenVersion,
+			active:   active,
+			verified: user.VerifiedAt != nil,
+		}
+		uc.sessions.set(claims.SessionID, state)
+	}
+
+	if !state.active || state.userId != claims.ID || state.version != claims.Version {
+		return nil, domainErr.ErrSessionRevoked
+	}
+
+	return &auth.Session{UserID: state.userId, EmailVerified: state.verified}, nil
 }
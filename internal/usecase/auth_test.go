@@ -0,0 +1,167 @@
+package usecase
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"routinist/internal/auth"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/domain/repository"
+	"routinist/internal/dto/request"
+	"routinist/pkg/logger"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// fakeAuthRepo keeps refresh tokens by hash. Like the repository, it only
+// rotates a token that is not revoked yet.
+type fakeAuthRepo struct {
+	repository.AuthRepository
+	db     *gorm.DB
+	tokens map[string]*model.RefreshToken
+	nextID uint
+	// revokeBeforeRotate revokes the token between its lookup and its
+	// rotation, as a concurrent refresh with the same token would
+	revokeBeforeRotate bool
+}
+
+func (r *fakeAuthRepo) GetDB() *gorm.DB { return r.db }
+
+func (r *fakeAuthRepo) addToken(familyId string, expiresAt time.Time) string {
+	token, hash, _ := auth.GenerateToken()
+	r.nextID++
+	r.tokens[hash] = &model.RefreshToken{ID: r.nextID, UserID: 1, FamilyID: familyId, TokenHash: hash, ExpiresAt: expiresAt}
+	return token
+}
+
+func (r *fakeAuthRepo) GetRefreshToken(tokenHash string) (*model.RefreshToken, error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return nil, domainErr.ErrInvalidRefreshToken
+	}
+
+	copied := *token
+	return &copied, nil
+}
+
+func (r *fakeAuthRepo) RotateRefreshToken(db *gorm.DB, old *model.RefreshToken, next *model.RefreshToken) error {
+	r.nextID++
+	next.ID = r.nextID
+	r.tokens[next.TokenHash] = next
+
+	stored := r.tokens[old.TokenHash]
+	if r.revokeBeforeRotate {
+		now := time.Now()
+		stored.RevokedAt = &now
+	}
+	if stored.RevokedAt != nil {
+		return domainErr.ErrRefreshTokenReused
+	}
+
+	now := time.Now()
+	stored.RevokedAt = &now
+	stored.ReplacedByID = &next.ID
+	return nil
+}
+
+func (r *fakeAuthRepo) RevokeRefreshTokenFamily(familyId string) error {
+	now := time.Now()
+	for _, token := range r.tokens {
+		if token.FamilyID == familyId && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *fakeAuthRepo) activeTokens(familyId string) int {
+	var n int
+	for _, token := range r.tokens {
+		if token.FamilyID == familyId && token.IsActive(time.Now()) {
+			n++
+		}
+	}
+	return n
+}
+
+func useTestKeyRing(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := auth.NewPrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ring, err := auth.NewKeyRing(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth.UseKeyRing(ring)
+}
+
+func TestRefresh(t *testing.T) {
+	useTestKeyRing(t)
+
+	tests := []struct {
+		name               string
+		expiresAt          time.Time
+		useTwice           bool // present the token again after rotating it
+		revokeBeforeRotate bool
+		wantErr            error
+		wantActive         int // active tokens left in the family
+	}{
+		{name: "rotates the token", expiresAt: time.Now().Add(time.Hour), wantActive: 1},
+		{name: "expired", expiresAt: time.Now().Add(-time.Minute), wantErr: domainErr.ErrInvalidRefreshToken},
+		{
+			name:      "reuse revokes the family",
+			expiresAt: time.Now().Add(time.Hour),
+			useTwice:  true,
+			wantErr:   domainErr.ErrRefreshTokenReused,
+		},
+		{
+			name:               "reuse during rotation revokes the family",
+			expiresAt:          time.Now().Add(time.Hour),
+			revokeBeforeRotate: true,
+			wantErr:            domainErr.ErrRefreshTokenReused,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAuthRepo{
+				db:                 newTestDB(t),
+				tokens:             map[string]*model.RefreshToken{},
+				revokeBeforeRotate: tt.revokeBeforeRotate,
+			}
+			users := &fakeUserRepo{user: &model.User{ID: 1, Email: "user@example.com"}}
+			uc := NewAuthUseCase(repo, nil, users, nil, nil, logger.New("error"))
+
+			token := repo.addToken("family", tt.expiresAt)
+			req := &request.RefreshTokenRequestDTO{RefreshToken: token}
+
+			res, err := uc.Refresh(req)
+			if tt.useTwice {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if res.RefreshToken == token {
+					t.Fatal("the refresh token was not rotated")
+				}
+				_, err = uc.Refresh(req)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if n := repo.activeTokens("family"); n != tt.wantActive {
+				t.Fatalf("%d active tokens left, want %d", n, tt.wantActive)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"fmt"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/domain/repository"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/pkg/logger"
+	"strings"
+	"time"
+)
+
+const maxDeviceTokenLength = 512
+
+type DeviceUseCase interface {
+	RegisterDevice(userId uint, req *request.RegisterDeviceRequestDTO) (*response.DeviceDto, error)
+	GetDevices(userId uint) ([]response.DeviceDto, error)
+	UnregisterDevice(userId uint, deviceId uint) error
+}
+
+type deviceUseCase struct {
+	repo   repository.DeviceRepository
+	logger *logger.Logger
+}
+
+func NewDeviceUseCase(r repository.DeviceRepository, l *logger.Logger) DeviceUseCase {
+	return &deviceUseCase{r, l}
+}
+
+// RegisterDevice adds a device to push notifications to. Apps call it on
+// every launch, which keeps the token and last-seen time fresh.
+func (uc *deviceUseCase) RegisterDevice(userId uint, req *request.RegisterDeviceRequestDTO) (*response.DeviceDto, error) {
+	token := strings.TrimSpace(req.Token)
+	if token == "" || len(token) > maxDeviceTokenLength {
+		return nil, domainErr.ErrInvalidDeviceToken
+	}
+
+	platform := model.Platform(strings.ToLower(req.Platform))
+	if !platform.IsValid() {
+		return nil, domainErr.ErrInvalidPlatform
+	}
+
+	device := &model.Device{
+		UserID:     userId,
+		Token:      token,
+		Platform:   platform,
+		AppVersion: req.AppVersion,
+		LastSeenAt: time.Now(),
+	}
+
+	if err := uc.repo.RegisterDevice(device); err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+
+	result := response.ToDeviceDto(device)
+	return &result, nil
+}
+
+func (uc *deviceUseCase) GetDevices(userId uint) ([]response.DeviceDto, error) {
+	devices, err := uc.repo.GetDevices(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.DeviceDto, 0, len(devices))
+	for i := range devices {
+		result = append(result, response.ToDeviceDto(&devices[i]))
+	}
+
+	return result, nil
+}
+
+func (uc *deviceUseCase) UnregisterDevice(userId uint, deviceId uint) error {
+	device, err := uc.repo.GetDevice(userId, deviceId)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.DeleteDevice(device.ID); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to unregister device: %w", err)
+	}
+
+	return nil
+}
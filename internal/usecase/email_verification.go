@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"routinist/internal/auth"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/mail"
+	"time"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+
+	// A verification email can be resent once a minute, five times a day
+	verificationResendInterval = time.Minute
+	verificationDailyLimit     = 5
+)
+
+// ResendEmailVerification sends the user a new verification email, unless
+// they are already verified or asked too often.
+func (uc *authUseCase) ResendEmailVerification(userId uint) error {
+	user, err := uc.userRepo.GetUser(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	if user.VerifiedAt != nil {
+		return domainErr.ErrEmailAlreadyVerified
+	}
+
+	if err := uc.checkVerificationRate(userId); err != nil {
+		return err
+	}
+
+	return uc.sendEmailVerification(user, user.Email)
+}
+
+// checkVerificationRate fails with ErrTooManyRequests when the user was sent
+// a verification email too recently or too often today.
+func (uc *authUseCase) checkVerificationRate(userId uint) error {
+	now := time.Now()
+	recent, err := uc.repo.CountEmailVerificationTokens(userId, now.Add(-verificationResendInterval))
+	if err != nil {
+		return err
+	}
+
+	daily, err := uc.repo.CountEmailVerificationTokens(userId, now.Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if recent > 0 || daily >= verificationDailyLimit {
+		return domainErr.ErrTooManyRequests
+	}
+
+	return nil
+}
+
+// ConfirmEmailVerification marks the address a verification token was sent to
+// as the user's verified email.
+func (uc *authUseCase) ConfirmEmailVerification(token string) error {
+	verification, err := uc.repo.GetEmailVerificationToken(auth.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if verification.UsedAt != nil || !time.Now().Before(verification.ExpiresAt) {
+		return domainErr.ErrInvalidVerifyToken
+	}
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.repo.UseEmailVerificationToken(tx, verification); err != nil {
+			return err
+		}
+
+		return uc.repo.MarkEmailVerified(tx, verification.UserID, verification.Email)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	uc.sessions.forgetUser(verification.UserID)
+	return nil
+}
+
+// sendEmailVerification mails a verification token for email to the user.
+func (uc *authUseCase) sendEmailVerification(user *model.User, email string) error {
+	token, hash, err := auth.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	err = uc.repo.CreateEmailVerificationToken(&model.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     email,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(emailVerificationTTL),
+	})
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to create email verification token: %w", err)
+	}
+
+	err = uc.mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Verify your Routinist email",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Use this code to verify your email address. It expires in 24 hours.\n\n"+
+			"%s\n",
+			user.Name, token),
+	})
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
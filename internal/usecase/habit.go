@@ -4,58 +4,104 @@ import (
 	"fmt"
 	"gorm.io/gorm"
 	"math/rand"
+	"routinist/internal/conversion"
+	domainErr "routinist/internal/domain/errors"
 	"routinist/internal/domain/model"
 	"routinist/internal/domain/repository"
+	"routinist/internal/dto/request"
 	"routinist/internal/dto/response"
+	"routinist/internal/events"
 	"routinist/internal/util"
 	"routinist/pkg/logger"
 	"time"
 )
 
 type HabitUsecase interface {
-	CreateUserHabit(userId uint, habitId uint, unitId *uint, goal *float64) (string, error)
+	CreateUserHabit(userId uint, habitId uint, unitId *uint, goal *float64, frequency model.GoalFrequency, schedule model.Schedule) (string, error)
 	GetRandomHabits() (*[]response.HabitDto, error)
+	CreateCustomHabit(userId uint, name string, icon string, measurement model.Measurement, unitIds []uint, defaultGoal float64) (*response.HabitDto, error)
+	GetCustomHabits(userId uint) ([]response.HabitDto, error)
+	GetUnits() ([]response.UnitDto, error)
 	GetTodayHabitProgresses(userId uint) ([]response.UserHabitProgressDto, error)
-	PostCreateHabitProgress(userId uint, userHabitId uint, value float64) (*response.CreateProgressDto, error)
-	GetProgressSummary(userID uint, from, to time.Time) (*response.ProgressSummaryDto, error)
+	PostCreateHabitProgress(userId uint, userHabitId uint, day time.Time, req *request.CreateHabitProgressRequestDTO) (*response.CreateProgressDto, error)
+	GetProgressEntries(userId uint, userHabitId uint, day time.Time) (*response.DailyProgressDto, error)
+	UpdateProgressEntry(userId uint, userHabitId uint, entryId uint, value *float64, note *string) (*response.DailyProgressDto, error)
+	DeleteProgressEntry(userId uint, userHabitId uint, entryId uint) (*response.DailyProgressDto, error)
+	GetProgressSummary(userID uint, mode string) (*response.ProgressSummaryDto, error)
 	GetActivitySummary(userID uint, userHabitId uint, from, to time.Time) (*response.ActivitySummaryDto, error)
-	GetUserHabits(userId uint) ([]response.UserHabitDto, error)
+	GetUserHabits(userId uint, includeArchived bool) ([]response.UserHabitDto, error)
+	UpdateUserHabit(userId uint, userHabitId uint, goal *float64, unitId *uint, frequency *model.GoalFrequency, schedule *model.Schedule) (*response.UserHabitDto, error)
+	ArchiveUserHabit(userId uint, userHabitId uint) error
+	RestoreUserHabit(userId uint, userHabitId uint) error
+	DeleteUserHabit(userId uint, userHabitId uint) error
 	GetUserHabitDailyStats(userID uint, from, to time.Time) ([]response.DailyHabitStat, error)
+	GetStreakHistory(userId uint, userHabitId uint) (*response.StreakHistoryDto, error)
+	ExcuseHabitDay(userId uint, userHabitId uint, day time.Time, status model.ProgressStatus) (*response.ExcuseProgressDto, error)
+	UpdateStreaks(e events.Event) error
 }
 
 type habitUseCase struct {
-	repo     repository.HabitRepository
-	userRepo repository.UserRepository
-	logger   *logger.Logger
+	repo       repository.HabitRepository
+	userRepo   repository.UserRepository
+	streakRepo repository.StreakRepository
+	outbox     repository.OutboxRepository
+	logger     *logger.Logger
+
+	// backfillDays is how many days back progress may be logged or excused.
+	backfillDays int
 }
 
-func NewHabitUseCase(r repository.HabitRepository, u repository.UserRepository, l *logger.Logger) HabitUsecase {
-	return &habitUseCase{r, u, l}
+func NewHabitUseCase(r repository.HabitRepository, u repository.UserRepository, s repository.StreakRepository, o repository.OutboxRepository, backfillDays int, l *logger.Logger) HabitUsecase {
+	return &habitUseCase{r, u, s, o, l, backfillDays}
 }
 
-func (uc *habitUseCase) CreateUserHabit(userId uint, habitId uint, unitId *uint, goal *float64) (string, error) {
+func (uc *habitUseCase) CreateUserHabit(userId uint, habitId uint, unitId *uint, goal *float64, frequency model.GoalFrequency, schedule model.Schedule) (string, error) {
 	var uh *model.UserHabit
 
+	if frequency != "" && !frequency.IsValid() {
+		return "", domainErr.ErrInvalidGoalFrequency
+	}
+
+	today, err := uc.today(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return "", err
+	}
+
+	if err := validateSchedule(&schedule, today); err != nil {
+		return "", err
+	}
+
 	db := uc.repo.GetDB()
-	err := db.Transaction(func(tx *gorm.DB) error {
+	err = db.Transaction(func(tx *gorm.DB) error {
 		var err error
 
-		uh, err = uc.repo.CreateUserHabit(tx, userId, habitId, unitId, goal)
+		uh, err = uc.repo.CreateUserHabit(tx, userId, habitId, unitId, goal, frequency, schedule)
 
 		if err != nil {
 			uc.logger.Error(err)
 			return fmt.Errorf("failed to create habit: %w", err)
 		}
 
-		if err := uc.repo.EnsureTodayProgressForUser(uh.UserID); err != nil {
+		if err := uc.repo.EnsureTodayProgressForUser(uh.UserID, today); err != nil {
 			uc.logger.Error(err)
 			return fmt.Errorf("failed to prepare today's habit progress: %w", err)
 		}
 
-		return nil
+		return appendEvents(uc.outbox, tx, events.New(events.HabitCreated, userId, map[string]interface{}{
+			"user_habit_id":  uh.ID,
+			"habit_id":       uh.HabitID,
+			"unit_id":        uh.UnitID,
+			"goal":           uh.Goal,
+			"goal_frequency": uh.GoalFrequency,
+		}))
 	})
 
-	return "success to create user habit", err
+	if err != nil {
+		return "", err
+	}
+
+	return "success to create user habit", nil
 }
 func (uc *habitUseCase) GetRandomHabits() (*[]response.HabitDto, error) {
 	habits, err := uc.repo.GetRandomHabits()
@@ -78,8 +124,58 @@ func (uc *habitUseCase) GetRandomHabits() (*[]response.HabitDto, error) {
 	return &result, nil
 }
 
+func (uc *habitUseCase) CreateCustomHabit(userId uint, name string, icon string, measurement model.Measurement, unitIds []uint, defaultGoal float64) (*response.HabitDto, error) {
+	if !measurement.IsValid() {
+		return nil, domainErr.ErrInvalidMeasurement
+	}
+
+	if defaultGoal <= 0 {
+		return nil, domainErr.ErrInvalidGoal
+	}
+
+	habit, err := uc.repo.CreateCustomHabit(uc.repo.GetDB(), userId, name, icon, measurement, unitIds, defaultGoal)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to create custom habit: %w", err)
+	}
+
+	result := response.ToHabitDto(*habit, generateRandomColor())
+	return &result, nil
+}
+
+func (uc *habitUseCase) GetCustomHabits(userId uint) ([]response.HabitDto, error) {
+	habits, err := uc.repo.GetCustomHabits(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get custom habits: %w", err)
+	}
+
+	result := make([]response.HabitDto, len(habits))
+	for i, h := range habits {
+		result[i] = response.ToHabitDto(h, generateRandomColor())
+	}
+
+	return result, nil
+}
+
+func (uc *habitUseCase) GetUnits() ([]response.UnitDto, error) {
+	units, err := uc.repo.GetUnits()
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get units: %w", err)
+	}
+
+	return response.ToUnitDtos(units), nil
+}
+
 func (uc *habitUseCase) GetTodayHabitProgresses(userId uint) ([]response.UserHabitProgressDto, error) {
-	userHabits, err := uc.repo.GetTodayHabits(userId)
+	today, err := uc.today(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	userHabits, err := uc.repo.GetTodayHabits(userId, today)
 
 	if err != nil {
 		uc.logger.Error(err)
@@ -91,7 +187,7 @@ func (uc *habitUseCase) GetTodayHabitProgresses(userId uint) ([]response.UserHab
 		uids = append(uids, uh.ID)
 	}
 
-	progresses, err := uc.repo.GetTodayHabitProgresses(uids)
+	progresses, err := uc.repo.GetTodayHabitProgresses(uids, today)
 	if err != nil {
 		uc.logger.Error("Failed to fetch progress records: ", err)
 		return nil, err
@@ -102,18 +198,42 @@ func (uc *habitUseCase) GetTodayHabitProgresses(userId uint) ([]response.UserHab
 		progressMap[p.UserHabitID] = p
 	}
 
+	var uhs []*model.UserHabit
+	for i := range userHabits {
+		uhs = append(uhs, &userHabits[i])
+	}
+
+	streaks, err := uc.getStreaks(uhs)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
 	var result []response.UserHabitProgressDto
 
 	for _, u := range userHabits {
 		progress := progressMap[u.ID]
 
-		result = append(result, response.ToUserHabitProgressDto(&u, &progress))
+		// Weekly and monthly habits report what has been logged so far this period
+		periodProgress := progress.Value
+		if u.GoalFrequency != model.FrequencyDaily {
+			from, to := u.PeriodRange(today)
+			periodProgress, err = uc.repo.GetPeriodProgress(uc.repo.GetDB(), u.ID, from, to)
+			if err != nil {
+				uc.logger.Error("Failed to fetch period progress: ", err)
+				return nil, err
+			}
+		}
+
+		result = append(result, response.ToUserHabitProgressDto(&u, &progress, periodProgress, streaks[u.ID]))
 	}
 
 	return result, nil
 }
 
-func (uc *habitUseCase) PostCreateHabitProgress(userId uint, userHabitId uint, value float64) (*response.CreateProgressDto, error) {
+// PostCreateHabitProgress logs a progress entry on day, today when day is zero.
+// A value in another unit than the habit's is converted first.
+func (uc *habitUseCase) PostCreateHabitProgress(userId uint, userHabitId uint, day time.Time, req *request.CreateHabitProgressRequestDTO) (*response.CreateProgressDto, error) {
 	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
 
 	if err != nil {
@@ -121,34 +241,222 @@ func (uc *habitUseCase) PostCreateHabitProgress(userId uint, userHabitId uint, v
 		return nil, fmt.Errorf("failed to get habit: %w", err)
 	}
 
-	c, e := uc.repo.CreateProgress(uh.ID, value)
+	if uh.ArchivedAt != nil {
+		return nil, domainErr.ErrHabitArchived
+	}
 
-	if e != nil {
-		uc.logger.Error(e)
-		return nil, fmt.Errorf("failed to create habit progress: %w", e)
+	day, err = uc.progressDay(uh, day)
+	if err != nil {
+		return nil, err
 	}
 
-	if c.IsCompleted {
-		m, err := uc.userRepo.UpdateMilestone(userId, 1)
+	value := req.Value
+	if req.UnitId != 0 && req.UnitId != uh.UnitID {
+		unit, err := uc.repo.GetUnit(req.UnitId)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err = conversion.Convert(value, *unit, uh.Unit)
 		if err != nil {
 			return nil, err
 		}
+	}
+
+	source := model.EntrySourceManual
+	if req.Source != "" {
+		source = model.EntrySource(req.Source)
+		if !source.IsValid() {
+			return nil, domainErr.ErrInvalidEntrySource
+		}
+	}
+
+	// The entry, the milestone it may earn and its events are committed
+	// together. Streaks follow from the events.
+	var c *model.HabitProgress
+	var m uint
+	var es []events.Event
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
 
-		r := response.CreateProgressDto{
-			Milestone: m,
+		from, to := uh.PeriodRange(day)
+		before, err := uc.repo.GetPeriodProgress(tx, uh.ID, from, to)
+		if err != nil {
+			return err
+		}
+
+		c, err = uc.repo.CreateProgress(tx, uh.ID, day, value, req.Note, source)
+		if err != nil {
+			return fmt.Errorf("failed to create habit progress: %w", err)
+		}
+
+		m, err = uc.awardMilestone(tx, uh, c.Date, before, before+value)
+		if err != nil {
+			return err
 		}
-		return &r, nil
+
+		es = progressEvents(events.ProgressLogged, uh, c.Date, before, before+value, m, map[string]interface{}{
+			"value": value,
+		})
+		return appendEvents(uc.outbox, tx, es...)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
 	}
 
-	return &response.CreateProgressDto{}, nil
+	uc.applyStreakEvents(es)
+
+	progress := response.ToDailyProgressDto(c)
+	return &response.CreateProgressDto{
+		Milestone: m,
+		Progress:  &progress,
+	}, nil
 }
 
-func (uc *habitUseCase) GetProgressSummary(userID uint, from, to time.Time) (*response.ProgressSummaryDto, error) {
-	completed, total, err := uc.repo.GetProgressSummary(userID, from, to)
+// ExcuseHabitDay skips a day of a user habit, or freezes it by spending one of
+// the user's freeze tokens. A zero day means today.
+func (uc *habitUseCase) ExcuseHabitDay(userId uint, userHabitId uint, day time.Time, status model.ProgressStatus) (*response.ExcuseProgressDto, error) {
+	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	day, err = uc.progressDay(uh, day)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := uc.repo.GetProgressByDate(uh.ID, day)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get habit progress: %w", err)
+	}
+
+	result := response.ExcuseProgressDto{Date: day, Status: status}
+
+	// Excusing a day twice must not spend another freeze token
+	if existing == nil || existing.Status != status {
+		excused := events.New(events.ProgressExcused, userId, map[string]interface{}{
+			"user_habit_id": uh.ID,
+			"date":          day.Format(eventDateLayout),
+			"status":        status,
+		})
+
+		err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+			if status == model.ProgressStatusFrozen {
+				tokens, err := uc.userRepo.UseFreezeToken(tx, userId)
+				if err != nil {
+					return err
+				}
+				result.FreezeTokens = &tokens
+			}
+
+			if _, err := uc.repo.ExcuseProgress(tx, uh.ID, day, status); err != nil {
+				return err
+			}
+
+			return appendEvents(uc.outbox, tx, excused)
+		})
+
+		if err != nil {
+			uc.logger.Error(err)
+			return nil, err
+		}
+
+		uc.applyStreakEvents([]events.Event{excused})
+	}
+
+	streaks, err := uc.getStreaks([]*model.UserHabit{uh})
 	if err != nil {
 		uc.logger.Error(err)
 		return nil, err
 	}
+	result.Streak = streaks[uh.ID]
+
+	return &result, nil
+}
+
+// today is the current calendar day in the user's time zone.
+func (uc *habitUseCase) today(userId uint) (time.Time, error) {
+	user, err := uc.userRepo.GetUser(userId)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user.Today(), nil
+}
+
+// calendarRange turns a requested time range into the calendar days it covers
+// in the user's time zone.
+func (uc *habitUseCase) calendarRange(userId uint, from, to time.Time) (time.Time, time.Time, error) {
+	user, err := uc.userRepo.GetUser(userId)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	loc := user.Location()
+	return model.CalendarDay(from, loc), model.CalendarDay(to, loc), nil
+}
+
+// progressDay checks that progress can be recorded for a user habit on day:
+// not before the habit was created, not in the future and no further back
+// than the backfill window. A zero day means today.
+func (uc *habitUseCase) progressDay(uh *model.UserHabit, day time.Time) (time.Time, error) {
+	user, err := uc.userRepo.GetUser(uh.UserID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	today := user.Today()
+	if day.IsZero() {
+		return today, nil
+	}
+
+	day = day.Truncate(24 * time.Hour)
+	if day.After(today) ||
+		day.Before(model.CalendarDay(uh.CreatedAt, user.Location())) ||
+		day.Before(today.AddDate(0, 0, -uc.backfillDays)) {
+		return time.Time{}, domainErr.ErrInvalidProgressDate
+	}
+
+	return day, nil
+}
+
+// GetProgressSummary counts the completed habits of the user's current day,
+// week or month, as chosen by mode: today, this_week or this_month.
+func (uc *habitUseCase) GetProgressSummary(userID uint, mode string) (*response.ProgressSummaryDto, error) {
+	user, err := uc.userRepo.GetUser(userID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	frequency := model.FrequencyDaily
+	switch mode {
+	case "this_week":
+		frequency = model.FrequencyWeekly
+	case "this_month":
+		frequency = model.FrequencyMonthly
+	}
+
+	from, to := frequency.PeriodRange(user.Today(), user.Preferences.WeekStart)
+	userHabits, err := uc.repo.GetUserHabits(userID, true)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	progresses, err := uc.repo.GetUserHabitProgresses(userID, 0, from, to.AddDate(0, 0, -1))
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	completed, total := countGoalPeriods(userHabits, progresses)
 	percentage := 0.0
 	if total > 0 {
 		percentage = float64(completed) / float64(total) * 100
@@ -167,6 +475,12 @@ func (uc *habitUseCase) GetActivitySummary(userID uint, userHabitId uint, from,
 	var habitIcon string
 	var completedCount, failedCount int64
 
+	from, to, err := uc.calendarRange(userID, from, to)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
 	hp, err := uc.repo.GetUserHabitProgresses(userID, userHabitId, from, to)
 
 	if err != nil {
@@ -175,9 +489,12 @@ func (uc *habitUseCase) GetActivitySummary(userID uint, userHabitId uint, from,
 	}
 
 	for _, log := range hp {
+		if log.IsExcused() {
+			continue
+		}
 		if log.IsCompleted {
 			completedCount++
-		} else {
+		} else if !log.RestDay {
 			failedCount++
 		}
 	}
@@ -195,8 +512,14 @@ func (uc *habitUseCase) GetActivitySummary(userID uint, userHabitId uint, from,
 		habitIcon = uh.Habit.Icon
 	}
 
+	totals, err := uc.getValueTotals(userID, hp)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
 	percentage := 0.0
-	completed := len(hp)
+	completed := completedCount + failedCount
 	if completed > 0 {
 		percentage = float64(completedCount) / float64(completed) * 100
 	}
@@ -208,27 +531,282 @@ func (uc *habitUseCase) GetActivitySummary(userID uint, userHabitId uint, from,
 		UserHabitName: habitName,
 		UserHabitId:   habitId,
 		UserHabitIcon: habitIcon,
+		Totals:        totals,
 	}, nil
 }
 
-func (uc *habitUseCase) GetUserHabits(userId uint) ([]response.UserHabitDto, error) {
-	uh, err := uc.repo.GetUserHabits(userId)
+// getValueTotals sums logged progress per measurement, converting every value
+// to the canonical unit of its measurement so habits in km and m add up.
+func (uc *habitUseCase) getValueTotals(userID uint, hp []model.HabitProgress) ([]response.ValueTotalDto, error) {
+	userHabits, err := uc.repo.GetUserHabits(userID, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user habits: %w", err)
+	}
+
+	units := make(map[uint]model.Unit)
+	for _, uh := range userHabits {
+		units[uh.ID] = uh.Unit
+	}
+
+	var totals []response.ValueTotalDto
+	index := make(map[string]int)
+	for _, p := range hp {
+		unit, ok := units[p.UserHabitID]
+		if !ok || p.Value == 0 {
+			continue
+		}
+
+		symbol := conversion.BaseSymbol(unit)
+		i, ok := index[symbol]
+		if !ok {
+			i = len(totals)
+			index[symbol] = i
+			totals = append(totals, response.ValueTotalDto{Measurement: unit.Measurement, Unit: symbol})
+		}
+		totals[i].Value = util.RoundFloat(totals[i].Value+conversion.ToBase(p.Value, unit), 6)
+	}
+
+	return totals, nil
+}
+
+func (uc *habitUseCase) GetUserHabits(userId uint, includeArchived bool) ([]response.UserHabitDto, error) {
+	uh, err := uc.repo.GetUserHabits(userId, includeArchived)
 
 	if err != nil {
 		uc.logger.Error(err)
 		return nil, fmt.Errorf("failed to get user habit: %w", err)
 	}
 
+	streaks, err := uc.getStreaks(uh)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
 	var result []response.UserHabitDto
 
 	for _, u := range uh {
-		result = append(result, response.ToUserHabitDto(u))
+		result = append(result, response.ToUserHabitDto(u, streaks[u.ID]))
 	}
 
 	return result, nil
 }
 
+// UpdateUserHabit changes the goal, unit, frequency or schedule of a user
+// habit. Nil arguments are left unchanged. Past days keep their completion;
+// only today's progress is judged against the new settings.
+func (uc *habitUseCase) UpdateUserHabit(userId uint, userHabitId uint, goal *float64, unitId *uint, frequency *model.GoalFrequency, schedule *model.Schedule) (*response.UserHabitDto, error) {
+	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	today, err := uc.today(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	// Switching units converts the goal and the whole history so old values
+	// keep their meaning
+	var factor float64
+	if unitId != nil && *unitId != uh.UnitID {
+		var unit *model.Unit
+		for i, u := range uh.Habit.Units {
+			if u.ID == *unitId {
+				unit = &uh.Habit.Units[i]
+				break
+			}
+		}
+		if unit == nil {
+			return nil, domainErr.ErrInvalidUnit
+		}
+
+		factor, err = conversion.Convert(1, uh.Unit, *unit)
+		if err != nil {
+			return nil, err
+		}
+
+		uh.UnitID = unit.ID
+		uh.Goal, _ = conversion.Convert(uh.Goal, uh.Unit, *unit)
+	}
+
+	if goal != nil {
+		if *goal <= 0 {
+			return nil, domainErr.ErrInvalidGoal
+		}
+		uh.Goal = *goal
+	}
+
+	periodChanged := false
+	if frequency != nil {
+		if !frequency.IsValid() {
+			return nil, domainErr.ErrInvalidGoalFrequency
+		}
+		periodChanged = *frequency != uh.GoalFrequency
+		uh.GoalFrequency = *frequency
+	}
+
+	if schedule != nil {
+		if err := validateSchedule(schedule, today); err != nil {
+			return nil, err
+		}
+		periodChanged = true
+		uh.Schedule = *schedule
+	}
+
+	before, err := uc.repo.GetProgressByDate(uh.ID, today)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	// The habit, its converted history and today's completion are committed
+	// with their events. Streaks follow from the events.
+	var es []events.Event
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.repo.UpdateUserHabit(tx, uh); err != nil {
+			return err
+		}
+
+		if factor != 0 {
+			if err := uc.repo.ConvertProgressValues(tx, uh.ID, factor); err != nil {
+				return err
+			}
+		}
+
+		after, err := uc.repo.RecalculateCompletion(tx, uh.ID, today)
+		if err != nil {
+			return err
+		}
+
+		wasCompleted := before != nil && before.IsCompleted
+		isCompleted := after != nil && after.IsCompleted
+		date := today.Format(eventDateLayout)
+
+		es = []events.Event{events.New(events.HabitUpdated, userId, map[string]interface{}{
+			"user_habit_id":  uh.ID,
+			"date":           date,
+			"unit_id":        uh.UnitID,
+			"goal":           uh.Goal,
+			"goal_frequency": uh.GoalFrequency,
+			"period_changed": periodChanged,
+			"completed":      isCompleted,
+		})}
+		if isCompleted && !wasCompleted {
+			es = append(es, completedEvent(uh, date))
+		}
+
+		return appendEvents(uc.outbox, tx, es...)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to update habit: %w", err)
+	}
+
+	uc.applyStreakEvents(es)
+
+	updated, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	streaks, err := uc.getStreaks([]*model.UserHabit{updated})
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	result := response.ToUserHabitDto(updated, streaks[updated.ID])
+	return &result, nil
+}
+
+// ArchiveUserHabit hides a user habit from today's list, keeping its history.
+func (uc *habitUseCase) ArchiveUserHabit(userId uint, userHabitId uint) error {
+	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	if uh.ArchivedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	if err := uc.repo.SetUserHabitArchived(uh.ID, &now); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to archive habit: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *habitUseCase) RestoreUserHabit(userId uint, userHabitId uint) error {
+	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	if uh.ArchivedAt == nil {
+		return nil
+	}
+
+	if err := uc.repo.SetUserHabitArchived(uh.ID, nil); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to restore habit: %w", err)
+	}
+
+	today, err := uc.today(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	if err := uc.repo.EnsureTodayProgressForUser(userId, today); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to prepare today's habit progress: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteUserHabit permanently removes a user habit and all of its history.
+func (uc *habitUseCase) DeleteUserHabit(userId uint, userHabitId uint) error {
+	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.streakRepo.DeleteStreaks(tx, uh.ID); err != nil {
+			return err
+		}
+
+		return uc.repo.DeleteUserHabit(tx, uh.ID)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to delete habit: %w", err)
+	}
+
+	return nil
+}
+
 func (uc *habitUseCase) GetUserHabitDailyStats(userID uint, from, to time.Time) ([]response.DailyHabitStat, error) {
+	from, to, err := uc.calendarRange(userID, from, to)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
 	end := to
 	hp, err := uc.repo.GetUserHabitProgresses(userID, 0, from, to)
 
@@ -237,6 +815,18 @@ func (uc *habitUseCase) GetUserHabitDailyStats(userID uint, from, to time.Time)
 		return nil, err
 	}
 
+	userHabits, err := uc.repo.GetUserHabits(userID, true)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	// A weekly or monthly goal is met over its period, not on each day
+	daily := make(map[uint]bool)
+	for _, uh := range userHabits {
+		daily[uh.ID] = uh.GoalFrequency == model.FrequencyDaily
+	}
+
 	var result []response.DailyHabitStat
 
 	// For each day in the 7-day range (oldest → newest)
@@ -247,6 +837,9 @@ func (uc *habitUseCase) GetUserHabitDailyStats(userID uint, from, to time.Time)
 
 		for _, p := range hp {
 			r.Date = day
+			if (p.RestDay && !p.IsCompleted) || p.IsExcused() || !daily[p.UserHabitID] {
+				continue
+			}
 			if p.Date.Format("2006-01-02") == dayKey {
 				r.Total++
 
@@ -262,6 +855,78 @@ func (uc *habitUseCase) GetUserHabitDailyStats(userID uint, from, to time.Time)
 	return result, nil
 }
 
+// countGoalPeriods counts the goal periods the progresses belong to, and how
+// many of them were completed. A daily goal counts each day. A weekly or
+// monthly goal counts its period once, completed when the goal was reached on
+// any of its days. Unfinished rest days and excused days are not counted.
+func countGoalPeriods(userHabits []*model.UserHabit, progresses []model.HabitProgress) (completed int64, total int64) {
+	byId := make(map[uint]*model.UserHabit, len(userHabits))
+	for _, uh := range userHabits {
+		byId[uh.ID] = uh
+	}
+
+	type periodKey struct {
+		userHabitId uint
+		start       time.Time
+	}
+	periods := make(map[periodKey]bool)
+
+	for _, p := range progresses {
+		if (p.RestDay && !p.IsCompleted) || p.IsExcused() {
+			continue
+		}
+
+		uh, ok := byId[p.UserHabitID]
+		if !ok {
+			continue
+		}
+
+		start, _ := uh.PeriodRange(p.Date)
+		key := periodKey{uh.ID, start}
+		periods[key] = periods[key] || p.IsCompleted
+	}
+
+	for _, done := range periods {
+		total++
+		if done {
+			completed++
+		}
+	}
+
+	return completed, total
+}
+
+// validateSchedule checks a habit schedule, filling in the defaults for an
+// empty type and a missing interval start date, which becomes today. Weekdays
+// must name at least one day, and an interval is at least one day from a
+// start date.
+func validateSchedule(s *model.Schedule, today time.Time) error {
+	if s.Type == "" {
+		s.Type = model.ScheduleEveryDay
+	}
+
+	switch s.Type {
+	case model.ScheduleEveryDay:
+		return nil
+	case model.ScheduleWeekdays:
+		if !s.Weekdays.IsValid() {
+			return domainErr.ErrInvalidSchedule
+		}
+	case model.ScheduleInterval:
+		if s.Interval < 1 {
+			return domainErr.ErrInvalidSchedule
+		}
+		if s.StartDate.IsZero() {
+			s.StartDate = today
+		}
+		s.StartDate = s.StartDate.UTC().Truncate(24 * time.Hour)
+	default:
+		return domainErr.ErrInvalidSchedule
+	}
+
+	return nil
+}
+
 func generateRandomColor() float64 {
 	colors := []float64{
 		0xFFFFFFFF, 0xFFFCDCD3, 0xFFD7D9FF, 0xFFBBE5FA, 0xFFF7CECD,
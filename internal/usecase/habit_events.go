@@ -0,0 +1,168 @@
+package usecase
+
+import (
+	"errors"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/events"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const eventDateLayout = "2006-01-02"
+
+// StreaksConsumer is the name the streak subscriber is registered under with
+// the event relay.
+const StreaksConsumer = "streaks"
+
+// StreakEventTypes are the events UpdateStreaks follows up on.
+var StreakEventTypes = []events.Type{
+	events.ProgressLogged, events.ProgressChanged, events.ProgressExcused, events.HabitUpdated,
+}
+
+// progressData is the data of progress.logged and progress.changed events.
+type progressData struct {
+	UserHabitID   uint    `json:"user_habit_id"`
+	Date          string  `json:"date"`
+	PreviousTotal float64 `json:"previous_total"`
+	Total         float64 `json:"total"`
+	Goal          float64 `json:"goal"`
+	Completed     bool    `json:"completed"`
+}
+
+// dayData is the data of events about a day of a user habit, such as
+// progress.excused and habit.updated.
+type dayData struct {
+	UserHabitID uint   `json:"user_habit_id"`
+	Date        string `json:"date"`
+}
+
+// progressEvents returns the events of the total of the goal period containing
+// day going from before to after: t with data merged in, then habit.completed
+// when that reached the goal. milestone is the user's new milestone when this
+// earned one, zero otherwise.
+func progressEvents(t events.Type, uh *model.UserHabit, day time.Time, before, after float64, milestone uint, data map[string]interface{}) []events.Event {
+	date := day.Format(eventDateLayout)
+
+	payload := map[string]interface{}{
+		"user_habit_id":  uh.ID,
+		"date":           date,
+		"previous_total": before,
+		"total":          after,
+		"goal":           uh.Goal,
+		"completed":      after >= uh.Goal,
+	}
+	for k, v := range data {
+		payload[k] = v
+	}
+
+	result := []events.Event{events.New(t, uh.UserID, payload)}
+
+	if before < uh.Goal && after >= uh.Goal {
+		result = append(result, completedEvent(uh, date))
+	}
+
+	if milestone > 0 {
+		result = append(result, events.New(events.MilestoneReached, uh.UserID, map[string]interface{}{
+			"milestone":     milestone,
+			"user_habit_id": uh.ID,
+		}))
+	}
+
+	return result
+}
+
+func completedEvent(uh *model.UserHabit, date string) events.Event {
+	return events.New(events.HabitCompleted, uh.UserID, map[string]interface{}{
+		"user_habit_id":  uh.ID,
+		"date":           date,
+		"goal":           uh.Goal,
+		"goal_frequency": uh.GoalFrequency,
+	})
+}
+
+// UpdateStreaks follows up on an event that may change the streaks of a user
+// habit: a goal period completed or no longer completed, a day excused, or
+// the goal or schedule changed. The event is marked processed in the
+// transaction that changes the streaks, so it is applied once even when the
+// request and the relay handle it at the same time.
+func (uc *habitUseCase) UpdateStreaks(e events.Event) error {
+	return uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		first, err := uc.outbox.RecordProcessed(tx, StreaksConsumer, e.ID)
+		if err != nil || !first {
+			return err
+		}
+
+		return uc.updateStreaks(tx, e)
+	})
+}
+
+func (uc *habitUseCase) updateStreaks(tx *gorm.DB, e events.Event) error {
+	var data dayData
+	if err := e.Decode(&data); err != nil {
+		return err
+	}
+
+	day, err := time.Parse(eventDateLayout, data.Date)
+	if err != nil {
+		return err
+	}
+
+	uh, err := uc.repo.GetUserHabit(e.UserID, data.UserHabitID)
+	if errors.Is(err, domainErr.ErrHabitNotFound) {
+		// Deleted since, along with its streaks
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	switch e.Type {
+	case events.ProgressLogged, events.ProgressChanged:
+		var progress progressData
+		if err := e.Decode(&progress); err != nil {
+			return err
+		}
+
+		wasCompleted := progress.PreviousTotal >= progress.Goal
+		switch {
+		case progress.Completed && !wasCompleted:
+			return uc.updateStreak(tx, uh, day, true)
+		case wasCompleted && !progress.Completed:
+			return uc.rebuildStreaks(tx, uh)
+		}
+	case events.ProgressExcused:
+		return uc.updateStreak(tx, uh, day, false)
+	case events.HabitUpdated:
+		return uc.rebuildStreaks(tx, uh)
+	}
+
+	return nil
+}
+
+// applyStreakEvents runs UpdateStreaks on events that were just committed, so
+// that the response already shows their streaks. The relay skips the events
+// UpdateStreaks marked processed and retries the rest, so a failure here is
+// only logged.
+func (uc *habitUseCase) applyStreakEvents(es []events.Event) {
+	for _, e := range es {
+		if !isStreakEvent(e.Type) {
+			continue
+		}
+
+		if err := uc.UpdateStreaks(e); err != nil {
+			uc.logger.Error(err)
+			return
+		}
+	}
+}
+
+func isStreakEvent(t events.Type) bool {
+	for _, st := range StreakEventTypes {
+		if st == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"routinist/internal/domain/model"
+	"testing"
+	"time"
+)
+
+func TestCountGoalPeriods(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	day := func(i int) time.Time { return monday.AddDate(0, 0, i) }
+
+	user := model.User{Preferences: model.Preferences{WeekStart: time.Monday}}
+	weekly := &model.UserHabit{ID: 1, Goal: 3, GoalFrequency: model.FrequencyWeekly, User: user}
+	daily := &model.UserHabit{ID: 2, Goal: 1, GoalFrequency: model.FrequencyDaily, User: user}
+
+	pending := func(uh *model.UserHabit, d time.Time) model.HabitProgress {
+		return model.HabitProgress{UserHabitID: uh.ID, Date: d, Value: 1, Status: model.ProgressStatusPending}
+	}
+	completed := func(uh *model.UserHabit, d time.Time) model.HabitProgress {
+		return model.HabitProgress{UserHabitID: uh.ID, Date: d, Value: 1, IsCompleted: true, Status: model.ProgressStatusCompleted}
+	}
+
+	tests := []struct {
+		name          string
+		progresses    []model.HabitProgress
+		wantCompleted int64
+		wantTotal     int64
+	}{
+		{
+			// Only the day the total reached 3 is marked completed
+			name:          "weekly goal of 3 reached on day 3",
+			progresses:    []model.HabitProgress{pending(weekly, day(0)), pending(weekly, day(1)), completed(weekly, day(2))},
+			wantCompleted: 1,
+			wantTotal:     1,
+		},
+		{
+			name:          "weekly goal not reached yet",
+			progresses:    []model.HabitProgress{pending(weekly, day(0)), pending(weekly, day(1))},
+			wantCompleted: 0,
+			wantTotal:     1,
+		},
+		{
+			name:          "weekly goal over two weeks",
+			progresses:    []model.HabitProgress{completed(weekly, day(2)), pending(weekly, day(3)), pending(weekly, day(7))},
+			wantCompleted: 1,
+			wantTotal:     2,
+		},
+		{
+			name:          "daily goal counts each day",
+			progresses:    []model.HabitProgress{completed(daily, day(0)), pending(daily, day(1)), completed(daily, day(2))},
+			wantCompleted: 2,
+			wantTotal:     3,
+		},
+		{
+			name: "excused and unfinished rest days are left out",
+			progresses: []model.HabitProgress{
+				completed(daily, day(0)),
+				{UserHabitID: daily.ID, Date: day(1), Status: model.ProgressStatusSkipped},
+				{UserHabitID: daily.ID, Date: day(2), RestDay: true, Status: model.ProgressStatusPending},
+			},
+			wantCompleted: 1,
+			wantTotal:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completed, total := countGoalPeriods([]*model.UserHabit{weekly, daily}, tt.progresses)
+			if completed != tt.wantCompleted || total != tt.wantTotal {
+				t.Fatalf("got %d of %d completed, want %d of %d", completed, total, tt.wantCompleted, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	today := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		schedule  model.Schedule
+		wantErr   bool
+		wantStart time.Time
+	}{
+		{name: "empty type is every day", schedule: model.Schedule{}},
+		{name: "weekdays", schedule: model.Schedule{Type: model.ScheduleWeekdays, Weekdays: model.NewWeekdays(time.Monday, time.Friday)}},
+		{name: "every weekday", schedule: model.Schedule{Type: model.ScheduleWeekdays, Weekdays: model.EveryWeekday}},
+		{name: "no weekdays", schedule: model.Schedule{Type: model.ScheduleWeekdays}, wantErr: true},
+		{name: "only bit 7", schedule: model.Schedule{Type: model.ScheduleWeekdays, Weekdays: 0x80}, wantErr: true},
+		{name: "a day and bit 7", schedule: model.Schedule{Type: model.ScheduleWeekdays, Weekdays: 0x81}, wantErr: true},
+		{name: "interval without start", schedule: model.Schedule{Type: model.ScheduleInterval, Interval: 2}, wantStart: today},
+		{name: "interval with start", schedule: model.Schedule{Type: model.ScheduleInterval, Interval: 3, StartDate: start.Add(5 * time.Hour)}, wantStart: start},
+		{name: "interval of zero", schedule: model.Schedule{Type: model.ScheduleInterval, StartDate: start}, wantErr: true},
+		{name: "unknown type", schedule: model.Schedule{Type: "monthly"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.schedule
+			err := validateSchedule(&s, today)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error: %t", err, tt.wantErr)
+			}
+			if !tt.wantStart.IsZero() && !s.StartDate.Equal(tt.wantStart) {
+				t.Fatalf("start date is %s, want %s", s.StartDate, tt.wantStart)
+			}
+		})
+	}
+}
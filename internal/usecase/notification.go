@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"routinist/internal/domain/repository"
+	"routinist/internal/events"
+	"routinist/internal/notify"
+	"routinist/pkg/logger"
+	"strconv"
+)
+
+// NotificationUseCase tells users about what happened to their habits.
+type NotificationUseCase interface {
+	Notify(e events.Event) error
+}
+
+type notificationUseCase struct {
+	userRepo repository.UserRepository
+	notifier notify.Notifier
+	logger   *logger.Logger
+}
+
+func NewNotificationUseCase(u repository.UserRepository, notifier notify.Notifier, l *logger.Logger) NotificationUseCase {
+	return &notificationUseCase{u, notifier, l}
+}
+
+type milestoneReachedData struct {
+	Milestone uint `json:"milestone"`
+}
+
+type achievementUnlockedData struct {
+	Achievement string `json:"achievement"`
+	Name        string `json:"name"`
+}
+
+// Notify tells the user about a milestone.reached or achievement.unlocked
+// event. Other events are ignored.
+func (uc *notificationUseCase) Notify(e events.Event) error {
+	n := notify.Notification{Data: map[string]string{"event_id": e.ID}}
+
+	switch e.Type {
+	case events.MilestoneReached:
+		var data milestoneReachedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+
+		n.Title = "Milestone reached"
+		n.Body = fmt.Sprintf("You've reached %d goals. Keep it up!", data.Milestone)
+		n.Data["type"] = "milestone"
+		n.Data["milestone"] = strconv.FormatUint(uint64(data.Milestone), 10)
+	case events.AchievementUnlocked:
+		var data achievementUnlockedData
+		if err := e.Decode(&data); err != nil {
+			return err
+		}
+
+		n.Title = "Achievement unlocked"
+		n.Body = fmt.Sprintf("You've earned %s!", data.Name)
+		n.Data["type"] = "achievement"
+		n.Data["achievement"] = data.Achievement
+	default:
+		return nil
+	}
+
+	user, err := uc.userRepo.GetUser(e.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	n.UserID = user.ID
+	n.Email = user.Email
+	n.EmailOptIn = user.Preferences.EmailNotifications
+	n.PushOptIn = user.Preferences.PushNotifications
+
+	return uc.notifier.Notify(n)
+}
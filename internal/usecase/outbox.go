@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"gorm.io/gorm"
+	"routinist/internal/domain/model"
+	"routinist/internal/domain/repository"
+	"routinist/internal/events"
+	"routinist/pkg/logger"
+	"time"
+)
+
+const (
+	outboxRetryBaseDelay = 5 * time.Second
+	outboxRetryMaxDelay  = time.Hour
+	outboxLease          = time.Minute
+	outboxBatch          = 100
+
+	// outboxFirstAttemptDelay leaves the request that appended an event time
+	// to apply it itself before the relay picks it up.
+	outboxFirstAttemptDelay = 5 * time.Second
+)
+
+// EventRelay delivers the events in the outbox to the subscribers registered
+// in-process. An event is relayed until every subscriber handled it, so a
+// subscriber may see an event more than once but never misses one.
+type EventRelay interface {
+	Subscribe(consumer string, h events.Handler, types ...events.Type)
+	RelayDue(now time.Time) (int, error)
+}
+
+type subscriber struct {
+	consumer string
+	types    []events.Type
+	handle   events.Handler
+}
+
+func (s subscriber) wants(t events.Type) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+
+	for _, wanted := range s.types {
+		if wanted == t {
+			return true
+		}
+	}
+	return false
+}
+
+type eventRelay struct {
+	repo        repository.OutboxRepository
+	subscribers []subscriber
+	logger      *logger.Logger
+}
+
+func NewEventRelay(r repository.OutboxRepository, l *logger.Logger) EventRelay {
+	return &eventRelay{repo: r, logger: l}
+}
+
+// Subscribe registers h under the consumer name, for the given event types or
+// all of them. Subscribe before the relay starts; the name identifies the
+// subscriber's progress and must stay stable across releases.
+func (uc *eventRelay) Subscribe(consumer string, h events.Handler, types ...events.Type) {
+	uc.subscribers = append(uc.subscribers, subscriber{consumer, types, h})
+}
+
+// RelayDue relays the events that are due and returns how many were handled
+// by all their subscribers. An event a subscriber failed is retried later
+// with exponential backoff, only for the subscribers that did not handle it.
+func (uc *eventRelay) RelayDue(now time.Time) (int, error) {
+	due, err := uc.repo.GetDueEvents(now, outboxBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	relayed := 0
+	for i := range due {
+		e := &due[i]
+
+		claimed, err := uc.repo.ClaimEvent(e, now.Add(outboxLease))
+		if err != nil {
+			uc.logger.Error(err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if uc.relay(e) {
+			relayed++
+		}
+	}
+
+	return relayed, nil
+}
+
+func (uc *eventRelay) relay(oe *model.OutboxEvent) bool {
+	err := uc.dispatch(oe)
+
+	now := time.Now()
+	oe.Attempts++
+
+	if err == nil {
+		oe.PublishedAt = &now
+		oe.NextAttemptAt = nil
+		oe.LastError = ""
+	} else {
+		uc.logger.Error(err)
+		next := now.Add(retryDelay(oe.Attempts, outboxRetryBaseDelay, outboxRetryMaxDelay))
+		oe.NextAttemptAt = &next
+		oe.LastError = err.Error()
+	}
+
+	if err := uc.repo.UpdateEvent(oe); err != nil {
+		uc.logger.Error(err)
+	}
+
+	return oe.PublishedAt != nil
+}
+
+// dispatch hands the event to each subscriber that wants it and did not
+// handle it yet. It returns the first error, after trying every subscriber.
+func (uc *eventRelay) dispatch(oe *model.OutboxEvent) error {
+	var e events.Event
+	if err := json.Unmarshal([]byte(oe.Payload), &e); err != nil {
+		return fmt.Errorf("failed to decode event %s: %w", oe.EventID, err)
+	}
+
+	done, err := uc.repo.GetProcessedConsumers(e.ID)
+	if err != nil {
+		return err
+	}
+
+	processed := make(map[string]bool, len(done))
+	for _, c := range done {
+		processed[c] = true
+	}
+
+	var result error
+	for _, s := range uc.subscribers {
+		if processed[s.consumer] || !s.wants(e.Type) {
+			continue
+		}
+
+		if err := s.handle(e); err != nil {
+			if result == nil {
+				result = fmt.Errorf("%s failed to handle event %s: %w", s.consumer, e.ID, err)
+			}
+			continue
+		}
+
+		if err := uc.repo.MarkProcessed(s.consumer, e.ID); err != nil && result == nil {
+			result = err
+		}
+	}
+
+	return result
+}
+
+// toOutboxEvents prepares events to be appended to the outbox.
+func toOutboxEvents(es ...events.Event) ([]model.OutboxEvent, error) {
+	result := make([]model.OutboxEvent, 0, len(es))
+	for _, e := range es {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+
+		next := e.OccurredAt.Add(outboxFirstAttemptDelay)
+		result = append(result, model.OutboxEvent{
+			EventID:       e.ID,
+			Type:          string(e.Type),
+			UserID:        e.UserID,
+			Payload:       string(payload),
+			NextAttemptAt: &next,
+		})
+	}
+
+	return result, nil
+}
+
+// appendEvents writes events to the outbox in the transaction tx.
+func appendEvents(outbox repository.OutboxRepository, tx *gorm.DB, es ...events.Event) error {
+	rows, err := toOutboxEvents(es...)
+	if err != nil {
+		return err
+	}
+
+	return outbox.AppendEvents(tx, rows)
+}
+
+// retryDelay is the wait after the given number of failed attempts, doubling
+// from base up to max.
+func retryDelay(attempts int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"routinist/internal/auth"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/mail"
+	"time"
+)
+
+const passwordResetTTL = time.Hour
+
+// RequestPasswordReset mails a single-use reset token to the user with the
+// given email. An unknown email is not reported, so the endpoint cannot be
+// used to find out who has an account.
+func (uc *authUseCase) RequestPasswordReset(email string) error {
+	user, err := uc.repo.GetUserByEmail(email)
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	if user == nil {
+		return nil
+	}
+
+	token, hash, err := auth.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	err = uc.repo.CreatePasswordResetToken(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	})
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	err = uc.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Reset your Routinist password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Use this code to choose a new password. It expires in an hour.\n\n"+
+			"%s\n\n"+
+			"If you did not ask to reset your password, you can ignore this email.\n",
+			user.Name, token),
+	})
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to send password reset email: %w", err)
+	}
+
+	return nil
+}
+
+// ConfirmPasswordReset sets a new password with a reset token and logs the
+// user out everywhere.
+func (uc *authUseCase) ConfirmPasswordReset(token string, password string) error {
+	reset, err := uc.repo.GetPasswordResetToken(auth.HashToken(token))
+	if err != nil {
+		return err
+	}
+
+	if reset.UsedAt != nil || !time.Now().Before(reset.ExpiresAt) {
+		return domainErr.ErrInvalidResetToken
+	}
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.repo.UsePasswordResetToken(tx, reset); err != nil {
+			return err
+		}
+
+		if err := uc.repo.UpdatePassword(tx, reset.UserID, password); err != nil {
+			return err
+		}
+
+		if err := uc.userRepo.IncrementTokenVersion(tx, reset.UserID); err != nil {
+			return err
+		}
+
+		return uc.repo.RevokeUserRefreshTokens(tx, reset.UserID, "")
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	uc.sessions.forgetUser(reset.UserID)
+	return nil
+}
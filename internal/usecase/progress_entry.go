@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/dto/response"
+	"routinist/internal/events"
+	"time"
+)
+
+// awardMilestone gives the user a milestone when the total of a goal period
+// goes from before to after and reaches the goal. A period earns at most one
+// milestone, so going below the goal and back earns nothing more. It returns
+// the new milestone count when one was earned.
+func (uc *habitUseCase) awardMilestone(tx *gorm.DB, uh *model.UserHabit, day time.Time, before, after float64) (uint, error) {
+	if before >= uh.Goal || after < uh.Goal {
+		return 0, nil
+	}
+
+	period, _ := uh.PeriodRange(day)
+	m, err := uc.userRepo.AwardMilestone(tx, uh.UserID, uh.ID, period)
+	if err != nil {
+		return 0, fmt.Errorf("failed to award milestone: %w", err)
+	}
+
+	return m, nil
+}
+
+// GetProgressEntries lists the entries logged for a user habit on day, today
+// when day is zero.
+func (uc *habitUseCase) GetProgressEntries(userId uint, userHabitId uint, day time.Time) (*response.DailyProgressDto, error) {
+	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	if day.IsZero() {
+		if day, err = uc.today(userId); err != nil {
+			uc.logger.Error(err)
+			return nil, err
+		}
+	}
+
+	ph, err := uc.repo.GetProgressEntries(uh.ID, day)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get progress entries: %w", err)
+	}
+
+	if ph == nil {
+		ph = &model.HabitProgress{UserHabitID: uh.ID, Date: day, Status: model.ProgressStatusPending}
+	}
+
+	result := response.ToDailyProgressDto(ph)
+	return &result, nil
+}
+
+// UpdateProgressEntry edits the value or note of an entry. Nil arguments are
+// left unchanged.
+func (uc *habitUseCase) UpdateProgressEntry(userId uint, userHabitId uint, entryId uint, value *float64, note *string) (*response.DailyProgressDto, error) {
+	return uc.changeProgressEntry(userId, userHabitId, entryId, func(tx *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error) {
+		if value != nil {
+			entry.Value = *value
+		}
+		if note != nil {
+			entry.Note = *note
+		}
+		return uc.repo.UpdateProgressEntry(tx, entry)
+	})
+}
+
+func (uc *habitUseCase) DeleteProgressEntry(userId uint, userHabitId uint, entryId uint) (*response.DailyProgressDto, error) {
+	return uc.changeProgressEntry(userId, userHabitId, entryId, func(tx *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error) {
+		ph, err := uc.repo.DeleteProgressEntry(tx, entry)
+		entry.Value = 0
+		return ph, err
+	})
+}
+
+// changeProgressEntry applies change to an entry of the user's habit, then
+// follows up on the period total it changed. Entries can only be changed on
+// the days progress can be logged for. The change, the milestone it may earn
+// and its events are committed together. Streaks follow from the events.
+func (uc *habitUseCase) changeProgressEntry(userId uint, userHabitId uint, entryId uint, change func(tx *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error)) (*response.DailyProgressDto, error) {
+	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	if uh.ArchivedAt != nil {
+		return nil, domainErr.ErrHabitArchived
+	}
+
+	entry, err := uc.repo.GetProgressEntry(uh.ID, entryId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get progress entry: %w", err)
+	}
+
+	if _, err := uc.progressDay(uh, entry.Date); err != nil {
+		return nil, err
+	}
+
+	old := entry.Value
+
+	var ph *model.HabitProgress
+	var m uint
+	var es []events.Event
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		var err error
+
+		ph, err = change(tx, entry)
+		if err != nil {
+			return fmt.Errorf("failed to change progress entry: %w", err)
+		}
+
+		from, to := uh.PeriodRange(ph.Date)
+		after, err := uc.repo.GetPeriodProgress(tx, uh.ID, from, to)
+		if err != nil {
+			return err
+		}
+		before := after - entry.Value + old
+
+		m, err = uc.awardMilestone(tx, uh, ph.Date, before, after)
+		if err != nil {
+			return err
+		}
+
+		es = progressEvents(events.ProgressChanged, uh, ph.Date, before, after, m, map[string]interface{}{
+			"entry_id":       entry.ID,
+			"value":          entry.Value,
+			"previous_value": old,
+		})
+		return appendEvents(uc.outbox, tx, es...)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	uc.applyStreakEvents(es)
+
+	result := response.ToDailyProgressDto(ph)
+	result.Milestone = m
+	return &result, nil
+}
@@ -0,0 +1,384 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/domain/repository"
+	"routinist/internal/dto/request"
+	"routinist/internal/events"
+	"routinist/pkg/logger"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/utils/tests"
+)
+
+// noopConnPool lets gorm run transactions without a database. The fake
+// repositories below never send it a query.
+type noopConnPool struct{}
+
+var errNoDatabase = errors.New("no database in tests")
+
+func (*noopConnPool) PrepareContext(context.Context, string) (*sql.Stmt, error) {
+	return nil, errNoDatabase
+}
+
+func (*noopConnPool) ExecContext(context.Context, string, ...interface{}) (sql.Result, error) {
+	return nil, errNoDatabase
+}
+
+func (*noopConnPool) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errNoDatabase
+}
+
+func (*noopConnPool) QueryRowContext(context.Context, string, ...interface{}) *sql.Row {
+	return nil
+}
+
+func (p *noopConnPool) BeginTx(context.Context, *sql.TxOptions) (gorm.ConnPool, error) {
+	return &noopTx{}, nil
+}
+
+type noopTx struct{ noopConnPool }
+
+func (*noopTx) Commit() error   { return nil }
+func (*noopTx) Rollback() error { return nil }
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(tests.DummyDialector{}, &gorm.Config{ConnPool: &noopConnPool{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// fakeHabitRepo keeps the entries of a single user habit on a single day.
+type fakeHabitRepo struct {
+	repository.HabitRepository
+	db      *gorm.DB
+	uh      *model.UserHabit
+	day     time.Time
+	entries map[uint]*model.ProgressEntry
+	nextID  uint
+}
+
+func (r *fakeHabitRepo) GetDB() *gorm.DB { return r.db }
+
+func (r *fakeHabitRepo) GetUserHabit(userId uint, userHabitId uint) (*model.UserHabit, error) {
+	uh := *r.uh
+	return &uh, nil
+}
+
+func (r *fakeHabitRepo) GetProgressEntry(userHabitId uint, entryId uint) (*model.ProgressEntry, error) {
+	entry := *r.entries[entryId]
+	entry.Date = r.day
+	return &entry, nil
+}
+
+func (r *fakeHabitRepo) progress() *model.HabitProgress {
+	ph := &model.HabitProgress{ID: 1, UserHabitID: r.uh.ID, Date: r.day}
+	for _, e := range r.entries {
+		ph.Value += e.Value
+		ph.Entries = append(ph.Entries, *e)
+	}
+	ph.IsCompleted = ph.Value >= r.uh.Goal
+	return ph
+}
+
+func (r *fakeHabitRepo) CreateProgress(db *gorm.DB, userHabitId uint, day time.Time, value float64, note string, source model.EntrySource) (*model.HabitProgress, error) {
+	r.nextID++
+	r.entries[r.nextID] = &model.ProgressEntry{ID: r.nextID, HabitProgressID: 1, UserHabitID: userHabitId, Value: value}
+	return r.progress(), nil
+}
+
+func (r *fakeHabitRepo) UpdateProgressEntry(db *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error) {
+	saved := *entry
+	r.entries[entry.ID] = &saved
+	return r.progress(), nil
+}
+
+func (r *fakeHabitRepo) DeleteProgressEntry(db *gorm.DB, entry *model.ProgressEntry) (*model.HabitProgress, error) {
+	delete(r.entries, entry.ID)
+	return r.progress(), nil
+}
+
+func (r *fakeHabitRepo) GetPeriodProgress(db *gorm.DB, userHabitId uint, from, to time.Time) (float64, error) {
+	return r.progress().Value, nil
+}
+
+func (r *fakeHabitRepo) GetUserHabitProgresses(userId uint, userHabitId uint, from, to time.Time) ([]model.HabitProgress, error) {
+	return []model.HabitProgress{*r.progress()}, nil
+}
+
+// fakeUserRepo awards milestones once per period, as the unique key of
+// milestone_awards does.
+type fakeUserRepo struct {
+	repository.UserRepository
+	user   *model.User
+	awards map[string]bool
+}
+
+func (r *fakeUserRepo) GetUser(userId uint) (*model.User, error) {
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepo) AwardMilestone(db *gorm.DB, userId uint, userHabitId uint, period time.Time) (uint, error) {
+	key := fmt.Sprintf("%d/%s", userHabitId, period.Format(time.RFC3339))
+	if r.awards[key] {
+		return 0, nil
+	}
+
+	r.awards[key] = true
+	r.user.Milestone++
+	return r.user.Milestone, nil
+}
+
+// fakeStreakRepo keeps the streaks of a single user habit.
+type fakeStreakRepo struct {
+	repository.StreakRepository
+	streaks []model.Streak
+	reads   int
+}
+
+func (r *fakeStreakRepo) GetLatestStreak(db *gorm.DB, userHabitId uint) (*model.Streak, error) {
+	r.reads++
+	if len(r.streaks) == 0 {
+		return nil, nil
+	}
+	latest := r.streaks[len(r.streaks)-1]
+	return &latest, nil
+}
+
+func (r *fakeStreakRepo) SaveStreak(db *gorm.DB, streak *model.Streak) error {
+	if streak.ID == 0 {
+		streak.ID = uint(len(r.streaks) + 1)
+		r.streaks = append(r.streaks, *streak)
+		return nil
+	}
+	r.streaks[streak.ID-1] = *streak
+	return nil
+}
+
+func (r *fakeStreakRepo) ReplaceStreaks(db *gorm.DB, userHabitId uint, streaks []model.Streak) error {
+	r.streaks = nil
+	for i := range streaks {
+		if err := r.SaveStreak(db, &model.Streak{
+			UserHabitID: streaks[i].UserHabitID, StartDate: streaks[i].StartDate, EndDate: streaks[i].EndDate,
+			Length: streaks[i].Length, LastCompletedDate: streaks[i].LastCompletedDate,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type fakeOutboxRepo struct {
+	repository.OutboxRepository
+	events    []model.OutboxEvent
+	processed map[string]bool
+}
+
+func (r *fakeOutboxRepo) AppendEvents(db *gorm.DB, events []model.OutboxEvent) error {
+	r.events = append(r.events, events...)
+	return nil
+}
+
+func (r *fakeOutboxRepo) RecordProcessed(db *gorm.DB, consumer string, eventId string) (bool, error) {
+	key := consumer + "/" + eventId
+	if r.processed[key] {
+		return false, nil
+	}
+	r.processed[key] = true
+	return true, nil
+}
+
+func (r *fakeOutboxRepo) count(t events.Type) int {
+	n := 0
+	for _, e := range r.events {
+		if e.Type == string(t) {
+			n++
+		}
+	}
+	return n
+}
+
+func newTestHabitUseCase(t *testing.T) (*habitUseCase, *fakeHabitRepo, *fakeUserRepo, *fakeOutboxRepo) {
+	user := &model.User{ID: 1}
+	habits := &fakeHabitRepo{
+		db:      newTestDB(t),
+		uh:      &model.UserHabit{ID: 1, UserID: user.ID, Goal: 10, GoalFrequency: model.FrequencyDaily, Schedule: model.Schedule{Type: model.ScheduleEveryDay}, User: *user},
+		day:     user.Today(),
+		entries: map[uint]*model.ProgressEntry{},
+	}
+	users := &fakeUserRepo{user: user, awards: map[string]bool{}}
+
+	outbox := &fakeOutboxRepo{processed: map[string]bool{}}
+
+	uc := NewHabitUseCase(habits, users, &fakeStreakRepo{}, outbox, 7, logger.New("error")).(*habitUseCase)
+	return uc, habits, users, outbox
+}
+
+func TestEditingEntryBackOverGoalAwardsNoNewMilestone(t *testing.T) {
+	uc, habits, users, _ := newTestHabitUseCase(t)
+
+	created, err := uc.PostCreateHabitProgress(1, 1, time.Time{}, &request.CreateHabitProgressRequestDTO{Value: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.Milestone != 1 {
+		t.Fatalf("reaching the goal earned milestone %d, want 1", created.Milestone)
+	}
+
+	var entryId uint
+	for id := range habits.entries {
+		entryId = id
+	}
+
+	for i, value := range []float64{5, 10, 3, 12} {
+		v := value
+		result, err := uc.UpdateProgressEntry(1, 1, entryId, &v, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Milestone != 0 {
+			t.Fatalf("edit %d to %g earned milestone %d", i, value, result.Milestone)
+		}
+	}
+
+	// Deleting the entry and logging it again is the same period
+	if _, err := uc.DeleteProgressEntry(1, 1, entryId); err != nil {
+		t.Fatal(err)
+	}
+
+	relogged, err := uc.PostCreateHabitProgress(1, 1, time.Time{}, &request.CreateHabitProgressRequestDTO{Value: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if relogged.Milestone != 0 {
+		t.Fatalf("logging the period again earned milestone %d", relogged.Milestone)
+	}
+
+	if users.user.Milestone != 1 {
+		t.Fatalf("user has %d milestones, want 1", users.user.Milestone)
+	}
+}
+
+func TestEditingEntryAppendsEvents(t *testing.T) {
+	uc, habits, _, outbox := newTestHabitUseCase(t)
+
+	if _, err := uc.PostCreateHabitProgress(1, 1, time.Time{}, &request.CreateHabitProgressRequestDTO{Value: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	var entryId uint
+	for id := range habits.entries {
+		entryId = id
+	}
+
+	value := 10.0
+	if _, err := uc.UpdateProgressEntry(1, 1, entryId, &value, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uc.DeleteProgressEntry(1, 1, entryId); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := outbox.count(events.ProgressChanged); n != 2 {
+		t.Fatalf("got %d progress.changed events, want 2", n)
+	}
+	if n := outbox.count(events.HabitCompleted); n != 1 {
+		t.Fatalf("got %d habit.completed events, want 1", n)
+	}
+	if n := outbox.count(events.MilestoneReached); n != 1 {
+		t.Fatalf("got %d milestone.reached events, want 1", n)
+	}
+}
+
+func TestStreakEventIsAppliedOnce(t *testing.T) {
+	uc, _, _, outbox := newTestHabitUseCase(t)
+
+	if _, err := uc.PostCreateHabitProgress(1, 1, time.Time{}, &request.CreateHabitProgressRequestDTO{Value: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The relay handling the events the request already applied
+	for _, oe := range outbox.events {
+		var e events.Event
+		if err := json.Unmarshal([]byte(oe.Payload), &e); err != nil {
+			t.Fatal(err)
+		}
+		if !isStreakEvent(e.Type) {
+			continue
+		}
+		if err := uc.UpdateStreaks(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without the marker both would read no streak and each start one
+	streaks := uc.streakRepo.(*fakeStreakRepo)
+	if streaks.reads != 1 {
+		t.Fatalf("streaks were read %d times, want 1", streaks.reads)
+	}
+	if len(streaks.streaks) != 1 || streaks.streaks[0].Length != 1 {
+		t.Fatalf("got streaks %+v, want one of length 1", streaks.streaks)
+	}
+}
+
+func TestChangingEntryChecksItsDay(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(habits *fakeHabitRepo)
+		wantErr error
+	}{
+		{
+			name:    "within the backfill window",
+			prepare: func(habits *fakeHabitRepo) { habits.day = habits.day.AddDate(0, 0, -7) },
+		},
+		{
+			name:    "before the backfill window",
+			prepare: func(habits *fakeHabitRepo) { habits.day = habits.day.AddDate(0, 0, -8) },
+			wantErr: domainErr.ErrInvalidProgressDate,
+		},
+		{
+			name: "before the habit was created",
+			prepare: func(habits *fakeHabitRepo) {
+				habits.uh.CreatedAt = time.Now()
+				habits.day = habits.day.AddDate(0, 0, -1)
+			},
+			wantErr: domainErr.ErrInvalidProgressDate,
+		},
+		{
+			name: "archived habit",
+			prepare: func(habits *fakeHabitRepo) {
+				archivedAt := time.Now()
+				habits.uh.ArchivedAt = &archivedAt
+			},
+			wantErr: domainErr.ErrHabitArchived,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, habits, _, _ := newTestHabitUseCase(t)
+			habits.entries[1] = &model.ProgressEntry{ID: 1, HabitProgressID: 1, UserHabitID: 1, Value: 4}
+			tt.prepare(habits)
+
+			value := 10.0
+			if _, err := uc.UpdateProgressEntry(1, 1, 1, &value, nil); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("edit: got error %v, want %v", err, tt.wantErr)
+			}
+
+			if _, err := uc.DeleteProgressEntry(1, 1, 1); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("delete: got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"fmt"
+	"routinist/internal/auth/oidc"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/dto/request"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// LoginWithProvider signs a user in with an ID token from one of the
+// configured OpenID Connect providers. A known identity signs in its user.
+// Otherwise the provider must have verified the email: an account with that
+// email is linked, or a new one is created.
+func (uc *authUseCase) LoginWithProvider(provider string, req *request.ProviderLoginRequestDTO) (*request.AuthResponseDTO, error) {
+	verifier, ok := uc.providers[provider]
+	if !ok {
+		return nil, domainErr.ErrUnknownProvider
+	}
+
+	if req.TimeZone != "" && !model.ValidTimeZone(req.TimeZone) {
+		return nil, domainErr.ErrInvalidTimeZone
+	}
+
+	idToken, err := verifier.Verify(req.IDToken)
+	if err != nil {
+		uc.logger.Warn("rejected %s ID token: %v", provider, err)
+		return nil, domainErr.ErrInvalidIDToken
+	}
+
+	identity, err := uc.repo.GetExternalIdentity(provider, idToken.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var userId uint
+	if identity != nil {
+		userId = identity.UserID
+	} else {
+		userId, err = uc.linkIdentity(provider, idToken, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	user, err := uc.userRepo.GetUser(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	return uc.startSession(user)
+}
+
+// linkIdentity links a new provider identity to the account with its
+// verified email, creating the account when there is none.
+func (uc *authUseCase) linkIdentity(provider string, idToken *oidc.IDToken, req *request.ProviderLoginRequestDTO) (uint, error) {
+	email := strings.TrimSpace(idToken.Email)
+	if email == "" || !idToken.EmailVerified {
+		return 0, domainErr.ErrEmailNotVerified
+	}
+
+	existing, err := uc.repo.GetUserByEmail(email)
+	if err != nil {
+		return 0, err
+	}
+
+	name := req.Name
+	if name == "" {
+		name = idToken.Name
+	}
+
+	var userId uint
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if existing != nil {
+			userId = existing.ID
+
+			// Whoever registered an unverified account with this email never
+			// proved they own it, so their password and sessions are dropped
+			if existing.VerifiedAt == nil {
+				if err := uc.takeOverUnverified(tx, existing.ID, email); err != nil {
+					return err
+				}
+			}
+		} else {
+			user, err := uc.repo.RegisterExternal(tx, email, name, req.TimeZone)
+			if err != nil {
+				return err
+			}
+			userId = user.ID
+
+			if req.HabitID != 0 {
+				_, err = uc.habitRepo.CreateUserHabit(tx, user.ID, req.HabitID, nil, nil, model.FrequencyDaily, model.Schedule{Type: model.ScheduleEveryDay})
+				if err != nil {
+					return fmt.Errorf("failed to create habit: %w", err)
+				}
+			}
+		}
+
+		return uc.repo.CreateExternalIdentity(tx, &model.ExternalIdentity{
+			UserID:   userId,
+			Provider: provider,
+			Subject:  idToken.Subject,
+			Email:    email,
+		})
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return 0, fmt.Errorf("failed to link %s identity: %w", provider, err)
+	}
+
+	if existing != nil && existing.VerifiedAt == nil {
+		uc.sessions.forgetUser(existing.ID)
+	}
+
+	return userId, nil
+}
+
+func (uc *authUseCase) takeOverUnverified(tx *gorm.DB, userId uint, email string) error {
+	if err := uc.repo.MarkEmailVerified(tx, userId, email); err != nil {
+		return err
+	}
+
+	if err := uc.repo.ClearPassword(tx, userId); err != nil {
+		return err
+	}
+
+	if err := uc.userRepo.IncrementTokenVersion(tx, userId); err != nil {
+		return err
+	}
+
+	return uc.repo.RevokeUserRefreshTokens(tx, userId, "")
+}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"fmt"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/domain/repository"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/internal/notify"
+	"routinist/pkg/logger"
+	"strconv"
+	"time"
+)
+
+const (
+	maxRemindersPerHabit = 10
+
+	// reminderGracePeriod is how late a reminder may still be sent, when the
+	// scheduler was down at its time. Later than that it would only annoy.
+	reminderGracePeriod = 30 * time.Minute
+
+	// reminderBatchSize is how many due reminders are loaded at a time.
+	reminderBatchSize = 500
+
+	// reminderRetryDelay is when a reminder that could not be checked is
+	// looked at again.
+	reminderRetryDelay = time.Minute
+)
+
+type ReminderUseCase interface {
+	GetReminders(userId uint, userHabitId uint) ([]response.ReminderDto, error)
+	CreateReminder(userId uint, userHabitId uint, req *request.CreateReminderRequestDTO) (*response.ReminderDto, error)
+	UpdateReminder(userId uint, userHabitId uint, reminderId uint, req *request.UpdateReminderRequestDTO) (*response.ReminderDto, error)
+	DeleteReminder(userId uint, userHabitId uint, reminderId uint) error
+	SendDueReminders(now time.Time) (int, error)
+}
+
+type reminderUseCase struct {
+	repo      repository.ReminderRepository
+	habitRepo repository.HabitRepository
+	notifier  notify.Notifier
+	logger    *logger.Logger
+}
+
+func NewReminderUseCase(r repository.ReminderRepository, habitRepo repository.HabitRepository, notifier notify.Notifier, l *logger.Logger) ReminderUseCase {
+	return &reminderUseCase{
+		repo:      r,
+		habitRepo: habitRepo,
+		notifier:  notifier,
+		logger:    l,
+	}
+}
+
+func (uc *reminderUseCase) GetReminders(userId uint, userHabitId uint) ([]response.ReminderDto, error) {
+	if _, err := uc.habitRepo.GetUserHabit(userId, userHabitId); err != nil {
+		return nil, err
+	}
+
+	reminders, err := uc.repo.GetReminders(userHabitId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.ReminderDto, 0, len(reminders))
+	for i := range reminders {
+		result = append(result, response.ToReminderDto(&reminders[i]))
+	}
+
+	return result, nil
+}
+
+func (uc *reminderUseCase) CreateReminder(userId uint, userHabitId uint, req *request.CreateReminderRequestDTO) (*response.ReminderDto, error) {
+	if !model.ValidReminderTime(req.Time) {
+		return nil, domainErr.ErrInvalidReminderTime
+	}
+
+	uh, err := uc.habitRepo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := uc.repo.GetReminders(uh.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) >= maxRemindersPerHabit {
+		return nil, domainErr.ErrTooManyReminders
+	}
+
+	reminder := &model.Reminder{
+		UserHabitID: uh.ID,
+		Time:        req.Time,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	reminder.NextDueAt = reminder.NextDue(time.Now().Add(-reminderGracePeriod), uh.User.Location())
+
+	if err := uc.repo.CreateReminder(reminder); err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+
+	result := response.ToReminderDto(reminder)
+	return &result, nil
+}
+
+func (uc *reminderUseCase) UpdateReminder(userId uint, userHabitId uint, reminderId uint, req *request.UpdateReminderRequestDTO) (*response.ReminderDto, error) {
+	if req.Time != nil && !model.ValidReminderTime(*req.Time) {
+		return nil, domainErr.ErrInvalidReminderTime
+	}
+
+	uh, err := uc.habitRepo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		return nil, err
+	}
+
+	reminder, err := uc.repo.GetReminder(userHabitId, reminderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Time != nil && *req.Time != reminder.Time {
+		reminder.Time = *req.Time
+		// A reminder moved to later today is sent again at its new time
+		reminder.LastSentOn = nil
+	}
+
+	if req.Enabled != nil {
+		reminder.Enabled = *req.Enabled
+	}
+	reminder.NextDueAt = reminder.NextDue(time.Now().Add(-reminderGracePeriod), uh.User.Location())
+
+	if err := uc.repo.UpdateReminder(reminder); err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to update reminder: %w", err)
+	}
+
+	result := response.ToReminderDto(reminder)
+	return &result, nil
+}
+
+func (uc *reminderUseCase) DeleteReminder(userId uint, userHabitId uint, reminderId uint) error {
+	if _, err := uc.habitRepo.GetUserHabit(userId, userHabitId); err != nil {
+		return err
+	}
+
+	reminder, err := uc.repo.GetReminder(userHabitId, reminderId)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.DeleteReminder(reminder); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+
+	return nil
+}
+
+// SendDueReminders notifies users whose reminders fell due in the last
+// reminderGracePeriod, in their own time zone. Reminders are skipped on days
+// the habit is not scheduled or was already completed or excused. Only the
+// reminders whose next due time has come are loaded, in batches, and each is
+// moved on to its next one. It returns how many were sent.
+func (uc *reminderUseCase) SendDueReminders(now time.Time) (int, error) {
+	sent := 0
+	for {
+		reminders, err := uc.repo.GetDueReminders(now, reminderBatchSize)
+		if err != nil {
+			return sent, err
+		}
+
+		for i := range reminders {
+			n, err := uc.sendDueReminder(&reminders[i], now)
+			if err != nil {
+				return sent, err
+			}
+			sent += n
+		}
+
+		// Every reminder of the batch is now due later, so the next batch
+		// holds others
+		if len(reminders) < reminderBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// sendDueReminder sends the reminder for the days it is due on and moves it
+// on to its next due time, or retries it soon when it could not be checked.
+func (uc *reminderUseCase) sendDueReminder(reminder *model.Reminder, now time.Time) (int, error) {
+	loc := reminder.UserHabit.User.Location()
+
+	sent := 0
+	retry := false
+
+	// A reminder just before midnight is still due just after it
+	today := model.CalendarDay(now, loc)
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		dueAt := reminder.DueAt(day, loc)
+		if now.Before(dueAt) || now.Sub(dueAt) > reminderGracePeriod {
+			continue
+		}
+
+		ok, err := uc.sendReminder(reminder, day, now)
+		if err != nil {
+			uc.logger.Error(err)
+			retry = true
+			continue
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	next := reminder.NextDue(now, loc)
+	if retry {
+		next = now.Add(reminderRetryDelay)
+	}
+
+	if err := uc.repo.SetNextDueAt(reminder.ID, next); err != nil {
+		return sent, fmt.Errorf("failed to reschedule reminder %d: %w", reminder.ID, err)
+	}
+
+	return sent, nil
+}
+
+func (uc *reminderUseCase) sendReminder(reminder *model.Reminder, day time.Time, now time.Time) (bool, error) {
+	if reminder.LastSentOn != nil && !reminder.LastSentOn.Before(day) {
+		return false, nil
+	}
+
+	uh := &reminder.UserHabit
+	done, err := isHabitDone(uc.habitRepo, uh, day)
+	if err != nil || done {
+		return false, err
+	}
+
+	sentOn := day
+	claimed := *reminder
+	claimed.LastSentOn = &sentOn
+
+	ok, err := uc.repo.ClaimReminder(reminder.ID, day, claimed.NextDue(now, uh.User.Location()))
+	if err != nil || !ok {
+		return false, err
+	}
+	reminder.LastSentOn = &sentOn
+
+	err = uc.notifier.Notify(notify.Notification{
+		UserID:     uh.UserID,
+		Email:      uh.User.Email,
+		Title:      fmt.Sprintf("Time for %s", uh.Habit.Name),
+		Body:       fmt.Sprintf("Your goal is %g %s %s. You've got this!", uh.Goal, uh.Unit.Symbol, uh.GoalFrequency.PeriodLabel()),
+		EmailOptIn: uh.User.Preferences.EmailNotifications,
+		PushOptIn:  uh.User.Preferences.PushNotifications,
+		Data: map[string]string{
+			"type":          "reminder",
+			"user_habit_id": strconv.FormatUint(uint64(uh.ID), 10),
+			"reminder_id":   strconv.FormatUint(uint64(reminder.ID), 10),
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to send reminder %d: %w", reminder.ID, err)
+	}
+
+	return true, nil
+}
+
+// isHabitDone reports whether there is nothing left to do for a habit on day:
+// the habit is not due, or the day's progress is completed or excused, or the
+// goal of its period has been reached.
+func isHabitDone(habitRepo repository.HabitRepository, uh *model.UserHabit, day time.Time) (bool, error) {
+	if uh.GoalFrequency != model.FrequencyWeekly && uh.GoalFrequency != model.FrequencyMonthly && !uh.Schedule.IsDueOn(day) {
+		return true, nil
+	}
+
+	progress, err := habitRepo.GetTodayHabitProgress(uh.ID, day)
+	if err != nil {
+		return false, err
+	}
+
+	if progress.IsCompleted || progress.IsExcused() {
+		return true, nil
+	}
+
+	from, to := uh.PeriodRange(day)
+	total, err := habitRepo.GetPeriodProgress(habitRepo.GetDB(), uh.ID, from, to)
+	if err != nil {
+		return false, err
+	}
+
+	return total >= uh.Goal, nil
+}
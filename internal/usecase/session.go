@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"sync"
+	"time"
+)
+
+// sessionCacheTTL bounds how long a revocation can go unnoticed by another
+// instance of the API. Revocations made by this instance apply immediately.
+const sessionCacheTTL = 30 * time.Second
+
+type sessionState struct {
+	userId   uint
+	version  uint
+	active   bool
+	verified bool
+}
+
+type cachedSession struct {
+	state     sessionState
+	expiresAt time.Time
+}
+
+// sessionCache keeps recent session checks in memory so that authenticating
+// a request does not need a database round trip.
+type sessionCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]cachedSession
+}
+
+func newSessionCache(ttl time.Duration) *sessionCache {
+	return &sessionCache{
+		ttl:      ttl,
+		sessions: make(map[string]cachedSession),
+	}
+}
+
+func (c *sessionCache) get(sessionId string) (sessionState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s, ok := c.sessions[sessionId]
+	if !ok {
+		return sessionState{}, false
+	}
+
+	if time.Now().After(s.expiresAt) {
+		delete(c.sessions, sessionId)
+		return sessionState{}, false
+	}
+
+	return s.state, true
+}
+
+func (c *sessionCache) set(sessionId string, state sessionState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Drop expired entries now and then so the map does not keep growing
+	now := time.Now()
+	if len(c.sessions) >= 10000 {
+		for id, s := range c.sessions {
+			if now.After(s.expiresAt) {
+				delete(c.sessions, id)
+			}
+		}
+	}
+
+	c.sessions[sessionId] = cachedSession{state: state, expiresAt: now.Add(c.ttl)}
+}
+
+func (c *sessionCache) forgetSession(sessionId string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.sessions, sessionId)
+}
+
+func (c *sessionCache) forgetUser(userId uint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, s := range c.sessions {
+		if s.state.userId == userId {
+			delete(c.sessions, id)
+		}
+	}
+}
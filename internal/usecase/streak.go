@@ -0,0 +1,208 @@
+package usecase
+
+import (
+	"fmt"
+	"gorm.io/gorm"
+	"routinist/internal/domain/model"
+	"routinist/internal/dto/response"
+	"sort"
+	"time"
+)
+
+// extendStreak applies a completed or excused (skipped, frozen) goal period to
+// the latest streak of a user habit. Excused periods keep a streak alive
+// without adding to its length, and never start one. It returns the streak to
+// save, or nil when nothing changed. Periods before the latest streak's end
+// are not handled here; the caller rebuilds the streaks instead.
+func extendStreak(uh *model.UserHabit, latest *model.Streak, period time.Time, completed bool) *model.Streak {
+	if latest != nil && period.Equal(latest.EndDate) {
+		if completed && period.After(latest.LastCompletedDate) {
+			latest.Length++
+			latest.LastCompletedDate = period
+			return latest
+		}
+		return nil
+	}
+
+	if latest != nil && !period.After(uh.NextDuePeriod(latest.EndDate)) {
+		latest.EndDate = period
+		if completed {
+			latest.Length++
+			latest.LastCompletedDate = period
+		}
+		return latest
+	}
+
+	if !completed {
+		return nil
+	}
+
+	return &model.Streak{
+		UserHabitID:       uh.ID,
+		StartDate:         period,
+		EndDate:           period,
+		Length:            1,
+		LastCompletedDate: period,
+	}
+}
+
+// buildStreaks computes every streak of a user habit from its progress history.
+func buildStreaks(uh *model.UserHabit, progresses []model.HabitProgress) []model.Streak {
+	sort.Slice(progresses, func(i, j int) bool {
+		return progresses[i].Date.Before(progresses[j].Date)
+	})
+
+	var runs []model.Streak
+	for _, p := range progresses {
+		if !p.IsCompleted && !p.IsExcused() {
+			continue
+		}
+
+		var latest *model.Streak
+		if len(runs) > 0 {
+			latest = &runs[len(runs)-1]
+		}
+
+		period, _ := uh.PeriodRange(p.Date)
+		if s := extendStreak(uh, latest, period, p.IsCompleted); s != nil && s != latest {
+			runs = append(runs, *s)
+		}
+	}
+
+	return runs
+}
+
+// currentStreak returns the length of the latest streak while it is alive,
+// that is until the next due period after it has passed without completion.
+func currentStreak(uh *model.UserHabit, latest *model.Streak, today time.Time) uint {
+	if latest == nil {
+		return 0
+	}
+
+	period, _ := uh.PeriodRange(today)
+	if period.After(uh.NextDuePeriod(latest.EndDate)) {
+		return 0
+	}
+
+	return latest.Length
+}
+
+// updateStreak records a completed or excused day of a user habit. Changes
+// after the latest streak are applied incrementally, older ones rebuild the
+// streaks from the progress history. Streaks are written in db's transaction.
+func (uc *habitUseCase) updateStreak(db *gorm.DB, uh *model.UserHabit, day time.Time, completed bool) error {
+	latest, err := uc.streakRepo.GetLatestStreak(db, uh.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get streak: %w", err)
+	}
+
+	period, _ := uh.PeriodRange(day)
+	if latest != nil && period.Before(latest.EndDate) {
+		return uc.rebuildStreaks(db, uh)
+	}
+
+	streak := extendStreak(uh, latest, period, completed)
+	if streak == nil {
+		return nil
+	}
+
+	if err := uc.streakRepo.SaveStreak(db, streak); err != nil {
+		return fmt.Errorf("failed to save streak: %w", err)
+	}
+
+	return nil
+}
+
+func (uc *habitUseCase) rebuildStreaks(db *gorm.DB, uh *model.UserHabit) error {
+	today, err := uc.today(uh.UserID)
+	if err != nil {
+		return err
+	}
+
+	progresses, err := uc.repo.GetUserHabitProgresses(uh.UserID, uh.ID, time.Time{}, today)
+	if err != nil {
+		return fmt.Errorf("failed to get habit progresses: %w", err)
+	}
+
+	if err := uc.streakRepo.ReplaceStreaks(db, uh.ID, buildStreaks(uh, progresses)); err != nil {
+		return fmt.Errorf("failed to rebuild streaks: %w", err)
+	}
+
+	return nil
+}
+
+// getStreaks builds the streak summary of each given user habit. The habits
+// all belong to the same user.
+func (uc *habitUseCase) getStreaks(userHabits []*model.UserHabit) (map[uint]response.StreakDto, error) {
+	result := make(map[uint]response.StreakDto)
+	if len(userHabits) == 0 {
+		return result, nil
+	}
+
+	var ids []uint
+	for _, uh := range userHabits {
+		ids = append(ids, uh.ID)
+	}
+
+	latest, err := uc.streakRepo.GetLatestStreaks(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest streaks: %w", err)
+	}
+
+	longest, err := uc.streakRepo.GetLongestStreaks(ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get longest streaks: %w", err)
+	}
+
+	today, err := uc.today(userHabits[0].UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, uh := range userHabits {
+		s, ok := latest[uh.ID]
+		if !ok {
+			result[uh.ID] = response.ToStreakDto(0, 0, nil)
+			continue
+		}
+		result[uh.ID] = response.ToStreakDto(currentStreak(uh, &s, today), longest[uh.ID], &s)
+	}
+
+	return result, nil
+}
+
+func (uc *habitUseCase) GetStreakHistory(userId uint, userHabitId uint) (*response.StreakHistoryDto, error) {
+	uh, err := uc.repo.GetUserHabit(userId, userHabitId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get habit: %w", err)
+	}
+
+	runs, err := uc.streakRepo.GetStreaks(uh.ID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get streaks: %w", err)
+	}
+
+	var latest *model.Streak
+	var longest uint
+	for i, s := range runs {
+		if i == 0 {
+			latest = &runs[0]
+		}
+		if s.Length > longest {
+			longest = s.Length
+		}
+	}
+
+	today, err := uc.today(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	streak := response.ToStreakDto(currentStreak(uh, latest, today), longest, latest)
+	result := response.ToStreakHistoryDto(uh.ID, streak, runs)
+
+	return &result, nil
+}
@@ -0,0 +1,297 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"routinist/internal/auth"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/dto/request"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	totpIssuer             = "Routinist"
+	recoveryCodeCount      = 10
+	loginChallengeTTL      = 5 * time.Minute
+	loginChallengeAttempts = 5
+)
+
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+// EnrollTwoFactor starts enrolling an authenticator, replacing any earlier
+// enrollment that was never confirmed. It is not required at login until
+// ConfirmTwoFactor.
+func (uc *authUseCase) EnrollTwoFactor(userId uint) (*request.TwoFactorEnrollmentDTO, error) {
+	current, err := uc.repo.GetTwoFactor(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.IsEnabled() {
+		return nil, domainErr.ErrTwoFactorEnabled
+	}
+
+	user, err := uc.userRepo.GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.repo.DeleteTwoFactor(tx, userId); err != nil {
+			return err
+		}
+
+		return uc.repo.CreateTwoFactor(tx, &model.TwoFactor{UserID: userId, Secret: secret})
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to enroll two-factor: %w", err)
+	}
+
+	return &request.TwoFactorEnrollmentDTO{
+		Secret: secret,
+		URI:    auth.TOTPURI(totpIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor enables the enrolled authenticator once the user proves
+// it works, and returns their recovery codes. They are only ever shown here.
+func (uc *authUseCase) ConfirmTwoFactor(userId uint, code string) ([]string, error) {
+	twoFactor, err := uc.repo.GetTwoFactor(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil {
+		return nil, domainErr.ErrTwoFactorNotEnabled
+	}
+
+	if twoFactor.IsEnabled() {
+		return nil, domainErr.ErrTwoFactorEnabled
+	}
+
+	step, ok := auth.ValidateTOTP(twoFactor.Secret, code, time.Now())
+	if !ok {
+		return nil, domainErr.ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.repo.EnableTwoFactor(tx, userId, step); err != nil {
+			return err
+		}
+
+		return uc.repo.ReplaceRecoveryCodes(tx, userId, hashes)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to enable two-factor: %w", err)
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off. It takes a current
+// code, so that a stolen session alone cannot remove the second factor, and
+// logs out every other session.
+func (uc *authUseCase) DisableTwoFactor(userId uint, sessionId string, code string) error {
+	if err := uc.checkSecondFactor(userId, code); err != nil {
+		return err
+	}
+
+	err := uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		if err := uc.repo.DeleteTwoFactor(tx, userId); err != nil {
+			return err
+		}
+
+		return uc.repo.RevokeUserRefreshTokens(tx, userId, sessionId)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to disable two-factor: %w", err)
+	}
+
+	uc.sessions.forgetUser(userId)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, for when they
+// used or lost them.
+func (uc *authUseCase) RegenerateRecoveryCodes(userId uint, code string) ([]string, error) {
+	if err := uc.checkSecondFactor(userId, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.repo.GetDB().Transaction(func(tx *gorm.DB) error {
+		return uc.repo.ReplaceRecoveryCodes(tx, userId, hashes)
+	})
+
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+
+	return codes, nil
+}
+
+// LoginChallengeUser returns the user a pending login challenge is for, so
+// that failed codes can be counted against the user across challenges.
+func (uc *authUseCase) LoginChallengeUser(challengeToken string) (uint, error) {
+	challenge, err := uc.openLoginChallenge(challengeToken)
+	if err != nil {
+		return 0, err
+	}
+
+	return challenge.UserID, nil
+}
+
+func (uc *authUseCase) openLoginChallenge(challengeToken string) (*model.LoginChallenge, error) {
+	challenge, err := uc.repo.GetLoginChallenge(auth.HashToken(challengeToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge.UsedAt != nil || !time.Now().Before(challenge.ExpiresAt) {
+		return nil, domainErr.ErrInvalidChallenge
+	}
+
+	return challenge, nil
+}
+
+// VerifyLoginChallenge finishes a login that asked for a second factor,
+// issuing the session tokens once the code is valid.
+func (uc *authUseCase) VerifyLoginChallenge(challengeToken string, code string) (*request.AuthResponseDTO, error) {
+	challenge, err := uc.openLoginChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.RecordChallengeAttempt(challenge.ID, loginChallengeAttempts); err != nil {
+		return nil, err
+	}
+
+	if err := uc.checkSecondFactor(challenge.UserID, code); err != nil {
+		return nil, err
+	}
+
+	if err := uc.repo.UseLoginChallenge(challenge.ID); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetUser(challenge.UserID)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	return uc.openSession(user)
+}
+
+// startSession logs the user in, or hands out a login challenge when they
+// have two-factor authentication.
+func (uc *authUseCase) startSession(user *model.User) (*request.AuthResponseDTO, error) {
+	twoFactor, err := uc.repo.GetTwoFactor(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !twoFactor.IsEnabled() {
+		return uc.openSession(user)
+	}
+
+	token, hash, err := auth.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.repo.CreateLoginChallenge(&model.LoginChallenge{
+		UserID:    user.ID,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(loginChallengeTTL),
+	})
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to create login challenge: %w", err)
+	}
+
+	return &request.AuthResponseDTO{TwoFactorRequired: true, ChallengeToken: token}, nil
+}
+
+func (uc *authUseCase) openSession(user *model.User) (*request.AuthResponseDTO, error) {
+	token, err := uc.issueTokens(uc.repo.GetDB(), user)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	err = uc.habitRepo.EnsureTodayProgressForUser(user.ID, user.Today())
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// checkSecondFactor accepts a TOTP code, once, or an unused recovery code.
+func (uc *authUseCase) checkSecondFactor(userId uint, code string) error {
+	twoFactor, err := uc.repo.GetTwoFactor(userId)
+	if err != nil {
+		return err
+	}
+
+	if !twoFactor.IsEnabled() {
+		return domainErr.ErrTwoFactorNotEnabled
+	}
+
+	if totpCodePattern.MatchString(code) {
+		step, ok := auth.ValidateTOTP(twoFactor.Secret, code, time.Now())
+		if !ok {
+			return domainErr.ErrInvalidTwoFactorCode
+		}
+		return uc.repo.UseTOTPStep(userId, step)
+	}
+
+	err = uc.repo.UseRecoveryCode(userId, auth.HashToken(auth.NormalizeRecoveryCode(code)))
+	if err == nil {
+		uc.logger.Warn("user %d used a recovery code", userId)
+	} else if !errors.Is(err, domainErr.ErrInvalidTwoFactorCode) {
+		uc.logger.Error(err)
+	}
+	return err
+}
+
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := auth.GenerateRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, auth.HashToken(code))
+	}
+
+	return codes, hashes, nil
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"fmt"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/domain/repository"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/pkg/logger"
+	"strings"
+)
+
+type UserUseCase interface {
+	GetProfile(userId uint) (*response.UserDto, error)
+	UpdateProfile(userId uint, req *request.UpdateUserRequestDTO) (*response.UserDto, error)
+	UpdateTimeZone(userId uint, timeZone string) error
+}
+
+type userUseCase struct {
+	repo      repository.UserRepository
+	habitRepo repository.HabitRepository
+	logger    *logger.Logger
+}
+
+func NewUserUseCase(r repository.UserRepository, habitRepo repository.HabitRepository, l *logger.Logger) UserUseCase {
+	return &userUseCase{
+		repo:      r,
+		habitRepo: habitRepo,
+		logger:    l,
+	}
+}
+
+func (uc *userUseCase) GetProfile(userId uint) (*response.UserDto, error) {
+	user, err := uc.repo.GetUser(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	result := response.ToUserDto(user)
+	return &result, nil
+}
+
+// UpdateProfile edits the user's name, gender and preferences.
+func (uc *userUseCase) UpdateProfile(userId uint, req *request.UpdateUserRequestDTO) (*response.UserDto, error) {
+	user, err := uc.repo.GetUser(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, domainErr.ErrInvalidName
+		}
+		user.Name = name
+	}
+
+	if req.Gender != nil {
+		gender := model.Gender(*req.Gender)
+		if !gender.IsValid() {
+			return nil, domainErr.ErrInvalidGender
+		}
+		user.Gender = gender
+	}
+
+	timeZoneChanged := false
+	if p := req.Preferences; p != nil {
+		if p.TimeZone != nil {
+			if !model.ValidTimeZone(*p.TimeZone) {
+				return nil, domainErr.ErrInvalidTimeZone
+			}
+			timeZoneChanged = *p.TimeZone != user.TimeZone
+			user.TimeZone = *p.TimeZone
+		}
+
+		if p.WeekStart != nil {
+			weekStart, ok := model.ParseWeekday(*p.WeekStart)
+			if !ok {
+				return nil, domainErr.ErrInvalidWeekStart
+			}
+			user.Preferences.WeekStart = weekStart
+		}
+
+		if p.UnitSystem != nil {
+			unitSystem := model.UnitSystem(*p.UnitSystem)
+			if !unitSystem.IsValid() {
+				return nil, domainErr.ErrInvalidUnitSystem
+			}
+			user.Preferences.UnitSystem = unitSystem
+		}
+
+		if n := p.Notifications; n != nil {
+			if n.Email != nil {
+				user.Preferences.EmailNotifications = *n.Email
+			}
+			if n.Push != nil {
+				user.Preferences.PushNotifications = *n.Push
+			}
+		}
+	}
+
+	if err := uc.repo.UpdateUser(user); err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if timeZoneChanged {
+		if err := uc.repo.RescheduleReminders(userId); err != nil {
+			uc.logger.Error(err)
+			return nil, fmt.Errorf("failed to reschedule reminders: %w", err)
+		}
+
+		if err := uc.habitRepo.EnsureTodayProgressForUser(userId, user.Today()); err != nil {
+			uc.logger.Error(err)
+			return nil, fmt.Errorf("failed to prepare today's habit progress: %w", err)
+		}
+	}
+
+	result := response.ToUserDto(user)
+	return &result, nil
+}
+
+// UpdateTimeZone moves the user to another time zone. Days already recorded
+// keep their dates; today's progress is prepared for the new local day and
+// reminders follow the new zone.
+func (uc *userUseCase) UpdateTimeZone(userId uint, timeZone string) error {
+	if !model.ValidTimeZone(timeZone) {
+		return domainErr.ErrInvalidTimeZone
+	}
+
+	if err := uc.repo.UpdateTimeZone(userId, timeZone); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to update time zone: %w", err)
+	}
+
+	if err := uc.repo.RescheduleReminders(userId); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to reschedule reminders: %w", err)
+	}
+
+	user, err := uc.repo.GetUser(userId)
+	if err != nil {
+		uc.logger.Error(err)
+		return err
+	}
+
+	if err := uc.habitRepo.EnsureTodayProgressForUser(userId, user.Today()); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to prepare today's habit progress: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"routinist/internal/auth"
+	domainErr "routinist/internal/domain/errors"
+	"routinist/internal/domain/model"
+	"routinist/internal/domain/repository"
+	"routinist/internal/dto/request"
+	"routinist/internal/dto/response"
+	"routinist/internal/events"
+	"routinist/internal/webhook"
+	"routinist/pkg/logger"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxWebhooksPerUser      = 10
+	maxWebhookAttempts      = 8
+	webhookRetryBaseDelay   = 30 * time.Second
+	webhookRetryMaxDelay    = 6 * time.Hour
+	webhookDeliveryLease    = time.Minute
+	webhookDeliveryBatch    = 100
+	defaultWebhookLogLength = 50
+	maxWebhookLogLength     = 200
+)
+
+type WebhookUseCase interface {
+	CreateSubscription(userId uint, req *request.CreateWebhookRequestDTO) (*response.WebhookSubscriptionDto, error)
+	GetSubscriptions(userId uint) ([]response.WebhookSubscriptionDto, error)
+	DeleteSubscription(userId uint, subscriptionId uint) error
+	GetDeliveries(userId uint, subscriptionId uint, status string, limit int) ([]response.WebhookDeliveryDto, error)
+	QueueDeliveries(e events.Event) error
+	DeliverDue(now time.Time) (int, error)
+}
+
+type webhookUseCase struct {
+	repo   repository.WebhookRepository
+	sender *webhook.Sender
+	logger *logger.Logger
+}
+
+func NewWebhookUseCase(r repository.WebhookRepository, sender *webhook.Sender, l *logger.Logger) WebhookUseCase {
+	return &webhookUseCase{r, sender, l}
+}
+
+// CreateSubscription subscribes a URL to the user's events. The signing
+// secret is returned only here.
+func (uc *webhookUseCase) CreateSubscription(userId uint, req *request.CreateWebhookRequestDTO) (*response.WebhookSubscriptionDto, error) {
+	if !webhook.ValidURL(req.URL) {
+		return nil, domainErr.ErrInvalidWebhookURL
+	}
+
+	types := req.Events
+	if len(types) == 0 {
+		for _, t := range events.Types {
+			types = append(types, string(t))
+		}
+	}
+
+	for _, t := range types {
+		if !events.Type(t).IsValid() {
+			return nil, domainErr.ErrInvalidEventType
+		}
+	}
+
+	existing, err := uc.repo.GetSubscriptions(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(existing) >= maxWebhooksPerUser {
+		return nil, domainErr.ErrTooManyWebhooks
+	}
+
+	secret, _, err := auth.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &model.WebhookSubscription{
+		UserID: userId,
+		URL:    req.URL,
+		Secret: "whsec_" + secret,
+		Events: strings.Join(types, ","),
+	}
+
+	if err := uc.repo.CreateSubscription(subscription); err != nil {
+		uc.logger.Error(err)
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	result := response.ToWebhookSubscriptionDto(subscription)
+	result.Secret = subscription.Secret
+	return &result, nil
+}
+
+func (uc *webhookUseCase) GetSubscriptions(userId uint) ([]response.WebhookSubscriptionDto, error) {
+	subscriptions, err := uc.repo.GetSubscriptions(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.WebhookSubscriptionDto, 0, len(subscriptions))
+	for i := range subscriptions {
+		result = append(result, response.ToWebhookSubscriptionDto(&subscriptions[i]))
+	}
+
+	return result, nil
+}
+
+func (uc *webhookUseCase) DeleteSubscription(userId uint, subscriptionId uint) error {
+	subscription, err := uc.repo.GetSubscription(userId, subscriptionId)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.repo.DeleteSubscription(uc.repo.GetDB(), subscription.ID); err != nil {
+		uc.logger.Error(err)
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the delivery log of a subscription, newest first.
+func (uc *webhookUseCase) GetDeliveries(userId uint, subscriptionId uint, status string, limit int) ([]response.WebhookDeliveryDto, error) {
+	subscription, err := uc.repo.GetSubscription(userId, subscriptionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = defaultWebhookLogLength
+	}
+	if limit > maxWebhookLogLength {
+		limit = maxWebhookLogLength
+	}
+
+	deliveries, err := uc.repo.GetDeliveries(subscription.ID, model.DeliveryStatus(status), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]response.WebhookDeliveryDto, 0, len(deliveries))
+	for i := range deliveries {
+		result = append(result, response.ToWebhookDeliveryDto(&deliveries[i]))
+	}
+
+	return result, nil
+}
+
+// QueueDeliveries queues a delivery of the event for each of the user's
+// subscriptions that wants it. They are sent by DeliverDue. Queuing an event
+// again leaves its existing deliveries alone.
+func (uc *webhookUseCase) QueueDeliveries(e events.Event) error {
+	subscriptions, err := uc.repo.GetSubscriptions(e.UserID)
+	if err != nil || len(subscriptions) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []model.WebhookDelivery
+	for _, s := range subscriptions {
+		if !s.Wants(string(e.Type)) {
+			continue
+		}
+
+		deliveries = append(deliveries, model.WebhookDelivery{
+			SubscriptionID: s.ID,
+			EventID:        e.ID,
+			EventType:      string(e.Type),
+			Payload:        string(payload),
+			Status:         model.DeliveryPending,
+			NextAttemptAt:  &now,
+		})
+	}
+
+	return uc.repo.CreateDeliveries(uc.repo.GetDB(), deliveries)
+}
+
+// DeliverDue attempts the deliveries that are due and returns how many
+// subscribers accepted. A failed attempt is retried later with exponential
+// backoff, up to maxWebhookAttempts times.
+func (uc *webhookUseCase) DeliverDue(now time.Time) (int, error) {
+	deliveries, err := uc.repo.GetDueDeliveries(now, webhookDeliveryBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range deliveries {
+		d := &deliveries[i]
+
+		claimed, err := uc.repo.ClaimDelivery(d, now.Add(webhookDeliveryLease))
+		if err != nil {
+			uc.logger.Error(err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		if uc.attempt(d) {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+func (uc *webhookUseCase) attempt(d *model.WebhookDelivery) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookDeliveryLease/2)
+	defer cancel()
+
+	status, err := uc.sender.Send(ctx, webhook.Request{
+		URL:        d.Subscription.URL,
+		Secret:     d.Subscription.Secret,
+		EventType:  d.EventType,
+		DeliveryID: strconv.FormatUint(uint64(d.ID), 10),
+		Payload:    []byte(d.Payload),
+	})
+
+	now := time.Now()
+	d.Attempts++
+	d.LastStatusCode = status
+
+	switch {
+	case err == nil:
+		d.Status = model.DeliverySent
+		d.LastError = ""
+		d.DeliveredAt = &now
+		d.NextAttemptAt = nil
+	case d.Attempts >= maxWebhookAttempts:
+		d.Status = model.DeliveryFailed
+		d.LastError = err.Error()
+		d.NextAttemptAt = nil
+	default:
+		next := now.Add(retryDelay(d.Attempts, webhookRetryBaseDelay, webhookRetryMaxDelay))
+		d.LastError = err.Error()
+		d.NextAttemptAt = &next
+	}
+
+	if err := uc.repo.UpdateDelivery(d); err != nil {
+		uc.logger.Error(err)
+	}
+
+	return d.Status == model.DeliverySent
+}
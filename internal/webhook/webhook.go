@@ -0,0 +1,127 @@
+// Package webhook signs and posts event payloads to subscriber URLs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Routinist-Event"
+	HeaderDelivery  = "X-Routinist-Delivery"
+	HeaderSignature = "X-Routinist-Signature"
+)
+
+var ErrForbiddenAddress = errors.New("webhook address is not publicly routable")
+
+// Sign returns the signature header of a payload sent at t:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<payload>">". Receivers
+// recompute it with the subscription's secret, and reject old timestamps to
+// stop replays.
+func Sign(secret string, t time.Time, payload []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidURL reports whether s is an absolute http or https URL.
+func ValidURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// Request is one delivery of an event to a subscriber.
+type Request struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryID string
+	Payload    []byte
+}
+
+// Sender posts deliveries. Unless private addresses are allowed, it refuses
+// to connect to loopback, private and link-local addresses, so that
+// subscribers cannot use it to reach our internal network.
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender(allowPrivate bool) *Sender {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		dialer.Control = publicOnly
+	}
+
+	return &Sender{
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// A redirect could point anywhere; subscribers must give the final URL
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// publicOnly refuses to dial an address that is not publicly routable. It runs
+// after name resolution, so a hostname resolving to an internal address is
+// refused too.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// Send posts the delivery and returns the response status. Only a 2xx status
+// counts as delivered.
+func (s *Sender) Send(ctx context.Context, req Request) (int, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, req.URL, bytes.NewReader(req.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "Routinist-Webhooks/1")
+	httpReq.Header.Set(HeaderEvent, req.EventType)
+	httpReq.Header.Set(HeaderDelivery, req.DeliveryID)
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, time.Now(), req.Payload))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
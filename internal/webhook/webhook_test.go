@@ -0,0 +1,143 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"event":"habit.completed"}`)
+	at := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name    string
+		secret  string
+		t       time.Time
+		payload []byte
+		want    string
+	}{
+		{
+			name:    "known signature",
+			secret:  "whsec_test",
+			t:       at,
+			payload: payload,
+			want:    "t=1700000000,v1=c174db17ec9096d50fb73bd26659c24ee842f5e6ffe0fe774e33b21a61625083",
+		},
+		{
+			name:    "empty payload",
+			secret:  "whsec_test",
+			t:       at,
+			payload: nil,
+			want:    "t=1700000000,v1=" + hmacHex("whsec_test", "1700000000."),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.t, tt.payload); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignDependsOnEveryInput(t *testing.T) {
+	payload := []byte(`{"event":"habit.completed"}`)
+	at := time.Unix(1700000000, 0)
+	base := Sign("whsec_test", at, payload)
+
+	others := map[string]string{
+		"secret":    Sign("whsec_other", at, payload),
+		"timestamp": Sign("whsec_test", at.Add(time.Second), payload),
+		"payload":   Sign("whsec_test", at, []byte(`{"event":"habit.missed"}`)),
+	}
+	for input, sig := range others {
+		if sig == base {
+			t.Errorf("changing the %s kept the signature", input)
+		}
+	}
+}
+
+func hmacHex(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestPublicOnly(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"[::ffff:127.0.0.1]:80", false},
+		{"10.0.0.5:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"[fd00::1]:80", false},
+		{"169.254.169.254:80", false}, // cloud metadata
+		{"[fe80::1]:80", false},
+		{"0.0.0.0:80", false},
+		{"[::]:80", false},
+		{"example.com:80", false}, // only resolved addresses are dialed
+		{"93.184.216.34", false},  // no port
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := publicOnly("tcp", tt.address, nil)
+			if tt.allowed && err != nil {
+				t.Fatalf("refused: %v", err)
+			}
+			if !tt.allowed && err == nil {
+				t.Fatal("allowed")
+			}
+		})
+	}
+}
+
+func TestSenderRefusesPrivateAddresses(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	req := Request{
+		URL:        server.URL,
+		Secret:     "whsec_test",
+		EventType:  "habit.completed",
+		DeliveryID: "1",
+		Payload:    []byte(`{}`),
+	}
+
+	if _, err := NewSender(false).Send(context.Background(), req); !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("got error %v, want %v", err, ErrForbiddenAddress)
+	}
+	if got != nil {
+		t.Fatal("the request reached the server")
+	}
+
+	status, err := NewSender(true).Send(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusNoContent {
+		t.Fatalf("got status %d, want %d", status, http.StatusNoContent)
+	}
+	if sig := got.Header.Get(HeaderSignature); !strings.HasPrefix(sig, "t=") || !strings.Contains(sig, ",v1=") {
+		t.Fatalf("unexpected signature header %q", sig)
+	}
+}
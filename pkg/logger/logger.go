@@ -15,8 +15,12 @@ type Interface interface {
 	Warn(message string, args ...interface{})
 	Error(message interface{}, args ...interface{})
 	Fatal(message interface{}, args ...interface{})
+	WithFields(fields Fields) Interface
 }
 
+// Fields are attached to every message of a logger as structured data.
+type Fields map[string]interface{}
+
 // Logger -.
 type Logger struct {
 	logger *zerolog.Logger
@@ -82,6 +86,15 @@ func (l *Logger) Fatal(message interface{}, args ...interface{}) {
 	os.Exit(1)
 }
 
+// WithFields returns a logger adding the fields to its messages.
+func (l *Logger) WithFields(fields Fields) Interface {
+	logger := l.logger.With().Fields(map[string]interface{}(fields)).Logger()
+
+	return &Logger{
+		logger: &logger,
+	}
+}
+
 func (l *Logger) log(message string, args ...interface{}) {
 	if len(args) == 0 {
 		l.logger.Info().Msg(message)